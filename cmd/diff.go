@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/bitrise-io/go-utils/colorstring"
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// unifiedDiff returns the unified diff between the existing content of the file at pth and the newly generated content.
+// If the file does not exist yet (isNew) the diff is generated against /dev/null.
+// Returns an empty string if the two contents are the same.
+func unifiedDiff(pth, existingContent, generatedContent string, isNew bool) (string, error) {
+	fromFile := "a/" + pth
+	if isNew {
+		fromFile = "/dev/null"
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        diffLines(existingContent),
+		B:        diffLines(generatedContent),
+		FromFile: fromFile,
+		ToFile:   "b/" + pth,
+		Context:  3,
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to generate diff for file (path: %s)", pth)
+	}
+	return diff, nil
+}
+
+// noNewlineMarker is printed after the last line of the content if it doesn't end with a newline, like diff -u does
+const noNewlineMarker = "\\ No newline at end of file\n"
+
+// splitLines splits the content into lines, keeping the line endings, the last line has no line ending if the content has none.
+// difflib.SplitLines would add an extra empty line if the content ends with a newline.
+func splitLines(content string) []string {
+	if content == "" {
+		return []string{}
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the lines of the content to diff (see splitLines).
+// If the content doesn't end with a newline, its last line is followed by noNewlineMarker,
+// so that the line differs from the same line ending with a newline.
+func diffLines(content string) []string {
+	lines := splitLines(content)
+	if len(lines) > 0 && !strings.HasSuffix(lines[len(lines)-1], "\n") {
+		lines[len(lines)-1] += "\n" + noNewlineMarker
+	}
+	return lines
+}
+
+// colorizeDiff colors the lines of a unified diff: removed lines red, added lines green, hunk headers cyan.
+func colorizeDiff(diff string) string {
+	lines := splitLines(diff)
+	for idx, aLine := range lines {
+		content := strings.TrimSuffix(aLine, "\n")
+		switch {
+		case strings.HasPrefix(content, "---"), strings.HasPrefix(content, "+++"):
+			// file headers are not colored
+		case strings.HasPrefix(content, "@@"):
+			lines[idx] = colorstring.Cyan(content) + "\n"
+		case strings.HasPrefix(content, "-"):
+			lines[idx] = colorstring.Red(content) + "\n"
+		case strings.HasPrefix(content, "+"):
+			lines[idx] = colorstring.Green(content) + "\n"
+		}
+	}
+	return strings.Join(lines, "")
+}
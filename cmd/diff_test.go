@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/go-utils/colorstring"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_unifiedDiff(t *testing.T) {
+	t.Log("Same content - empty diff")
+	{
+		diff, err := unifiedDiff("out.txt", "a\nb\n", "a\nb\n", false)
+		require.NoError(t, err)
+		require.Equal(t, "", diff)
+	}
+
+	t.Log("Changed content")
+	{
+		diff, err := unifiedDiff("out.txt", "a\nb\nc\n", "a\nB\nc\n", false)
+		require.NoError(t, err)
+		require.Equal(t, `--- a/out.txt
++++ b/out.txt
+@@ -1,3 +1,3 @@
+ a
+-b
++B
+ c
+`, diff)
+	}
+
+	t.Log("New file")
+	{
+		diff, err := unifiedDiff("out.txt", "", "a\n", true)
+		require.NoError(t, err)
+		require.Equal(t, `--- /dev/null
++++ b/out.txt
+@@ -0,0 +1 @@
++a
+`, diff)
+	}
+
+	t.Log("Missing newline at the end of the file")
+	{
+		diff, err := unifiedDiff("out.txt", "name: x", "name: x\n", false)
+		require.NoError(t, err)
+		require.Equal(t, `--- a/out.txt
++++ b/out.txt
+@@ -1 +1 @@
+-name: x
+\ No newline at end of file
++name: x
+`, diff)
+
+		diff, err = unifiedDiff("out.txt", "a\nb\n", "a\nB", false)
+		require.NoError(t, err)
+		require.Equal(t, `--- a/out.txt
++++ b/out.txt
+@@ -1,2 +1,2 @@
+ a
+-b
++B
+\ No newline at end of file
+`, diff)
+	}
+}
+
+func Test_colorizeDiff(t *testing.T) {
+	diff := `--- a/out.txt
++++ b/out.txt
+@@ -1,3 +1,3 @@
+ a
+-b
++B
+ c
+`
+	expected := "--- a/out.txt\n" +
+		"+++ b/out.txt\n" +
+		colorstring.Cyan("@@ -1,3 +1,3 @@") + "\n" +
+		" a\n" +
+		colorstring.Red("-b") + "\n" +
+		colorstring.Green("+B") + "\n" +
+		" c\n"
+	require.Equal(t, expected, colorizeDiff(diff))
+}
@@ -3,26 +3,29 @@ package cmd
 import (
 	"fmt"
 	"log"
-	"os"
-	"path/filepath"
-	"reflect"
+	"sort"
 	"strings"
-	"text/template"
-
-	"encoding/json"
 
 	"github.com/bitrise-io/go-utils/colorstring"
 	"github.com/bitrise-io/go-utils/fileutil"
-	"github.com/bitrise-io/go-utils/templateutil"
+	"github.com/bitrise-io/go-utils/pathutil"
 	"github.com/bitrise-io/gotgen/configs"
+	"github.com/bitrise-io/gotgen/generator"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v2"
 )
 
 var (
 	ggTemplateFilePathFlag = ""
 	outputFilePathFlag     = ""
+	recursiveFlag          = false
+	includeGlobsFlag       = []string{}
+	excludeGlobsFlag       = []string{}
+	sourceRootFlag         = ""
+	outputRootFlag         = ""
+	dryRunFlag             = false
+	checkFlag              = false
+	diffFlag               = false
 )
 
 // generateCmd represents the generate command
@@ -31,7 +34,8 @@ var generateCmd = &cobra.Command{
 	Short: "A brief description of your command",
 	Long: `A longer description that spans multiple lines and likely contains examples
 and usage of using your command.`,
-	RunE: generate,
+	SilenceUsage: true,
+	RunE:         generate,
 }
 
 func init() {
@@ -47,21 +51,92 @@ func init() {
 	// is called directly, e.g.:
 	generateCmd.Flags().StringVar(&ggTemplateFilePathFlag, "file", "", ".gg file path - if specified only this single .gg file will be used as input, instead of scanning the whole directory for .gg files")
 	generateCmd.Flags().StringVar(&outputFilePathFlag, "out-file-path", "", "Output file path")
+	addConfigFlags(generateCmd)
+	generateCmd.Flags().BoolVarP(&recursiveFlag, "recursive", "r", false, "Scan the whole directory tree of the source root for .gg files, not just the source root directory itself. Every generated file is written next to its template, or if an output root is set to the same relative path in the output root")
+	generateCmd.Flags().StringSliceVar(&includeGlobsFlag, "include", []string{}, "Only use the .gg files matching any of these globs (can be specified multiple times). A glob without a slash is matched against the file name, a glob with a slash against the path relative to the scanned directory")
+	generateCmd.Flags().StringSliceVar(&excludeGlobsFlag, "exclude", []string{".git", "vendor", "node_modules"}, "Skip the files and directories matching any of these globs (can be specified multiple times). Uses the same glob syntax as --include")
+	generateCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "Render every template, and print the path, status (new / changed / unchanged) and size of every output file, without writing anything to disk")
+	generateCmd.Flags().BoolVar(&checkFlag, "check", false, "Render every template and compare the result with the existing output files, without writing anything to disk. Prints a unified diff for every mismatch, and fails if any of the output files is missing or differs")
+	generateCmd.Flags().BoolVar(&diffFlag, "diff", false, "Render every template and print a colored unified diff between the existing output files and the newly rendered content, without writing anything to disk")
+	generateCmd.Flags().StringVar(&sourceRootFlag, "source-root", "", "Directory to search for .gg files in (default: source_root from the config, or the current directory)")
+	generateCmd.Flags().StringVar(&outputRootFlag, "output-root", "", "Directory to write the generated files into, with the same relative layout the templates have in the source root (default: output_root from the config, or next to the templates)")
+}
+
+// generateMode defines what generate does with the rendered content
+type generateMode string
+
+const (
+	// generateModeWrite writes the rendered content into the output files
+	generateModeWrite generateMode = "write"
+	// generateModeDryRun only prints the status of the output files
+	generateModeDryRun generateMode = "dry-run"
+	// generateModeCheck prints the diff of the output files which are not up to date, and fails if there's any
+	generateModeCheck generateMode = "check"
+	// generateModeDiff prints the colored diff of the output files which are not up to date
+	generateModeDiff generateMode = "diff"
+)
+
+func generateModeFromFlags() (generateMode, error) {
+	modes := []generateMode{}
+	if dryRunFlag {
+		modes = append(modes, generateModeDryRun)
+	}
+	if checkFlag {
+		modes = append(modes, generateModeCheck)
+	}
+	if diffFlag {
+		modes = append(modes, generateModeDiff)
+	}
+
+	if len(modes) > 1 {
+		return "", errors.New("Only one of the --dry-run, --check and --diff flags can be used at a time")
+	}
+	if len(modes) == 1 {
+		return modes[0], nil
+	}
+	return generateModeWrite, nil
 }
 
 func generate(cmd *cobra.Command, args []string) error {
+	mode, err := generateModeFromFlags()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
 	// Read Inventory
 	log.Println(colorstring.Blue("Reading GotGen config ..."))
-	ggConfContent, err := fileutil.ReadBytesFromFile(gotgenConfigFileName)
+	ggConf, err := readGotGenConfigFileWithoutOverrides(gotgenConfigFileName)
 	if err != nil {
-		return errors.Wrapf(err, "Failed to read GotGen config (%s) file", gotgenConfigFileName)
+		return errors.WithStack(err)
+	}
+	// strict mode only checks the keys of the config's inventory and inventory files, not the ones from the env and --set
+	configInventoryKeys := map[string]bool{}
+	for key := range ggConf.Inventory {
+		configInventoryKeys[key] = true
 	}
-	ggConf := configs.Model{}
-	if err := json.Unmarshal(ggConfContent, &ggConf); err != nil {
-		return errors.Wrap(err, "Failed to parse GotGen config (JSON)")
+	if ggConf, err = applyInventoryOverrides(ggConf); err != nil {
+		return errors.WithStack(err)
+	}
+	gen, err := generatorForConfig(ggConf)
+	if err != nil {
+		return errors.WithStack(err)
 	}
 	log.Println(colorstring.Green("[DONE] Reading GotGen config"))
 
+	tree := generator.TreeOptions{
+		SourceRoot: ggConf.SourceRoot,
+		OutputRoot: ggConf.OutputRoot,
+		Recursive:  recursiveFlag,
+		Includes:   includeGlobsFlag,
+		Excludes:   excludeGlobsFlag,
+	}
+	if len(sourceRootFlag) > 0 {
+		tree.SourceRoot = sourceRootFlag
+	}
+	if len(outputRootFlag) > 0 {
+		tree.OutputRoot = outputRootFlag
+	}
+
 	//
 	templateFiles := map[string]string{}
 	if len(ggTemplateFilePathFlag) > 0 {
@@ -71,333 +146,223 @@ func generate(cmd *cobra.Command, args []string) error {
 			if !strings.HasSuffix(ggTemplateFilePathFlag, ".gg") {
 				return errors.Errorf("If you specify an input file path that either has to be a .gg file (.gg extension) or also specify the out-file-path option to specify where the generated output should be stored")
 			}
-			oFilePth = strings.TrimSuffix(ggTemplateFilePathFlag, ".gg")
+			oFilePth = tree.DefaultOutputPath(ggTemplateFilePathFlag)
 		}
 		templateFiles[ggTemplateFilePathFlag] = oFilePth
 	} else {
 		log.Println(colorstring.Blue("Searching for templates ..."))
-		files, err := filepath.Glob("*.gg")
+		files, err := gen.FindTemplates(tree)
 		if err != nil {
-			return errors.Wrap(err, "Failed to scan .gg template files")
-		}
-		for _, aFilePath := range files {
-			templateFiles[aFilePath] = strings.TrimSuffix(aFilePath, ".gg")
+			return errors.WithStack(err)
 		}
+		templateFiles = files
 	}
 
 	if len(templateFiles) < 1 {
 		return errors.Errorf("No template file specified or found.")
 	}
 
-	log.Println(colorstring.Blue("Generating ..."))
+	if ggConf.Strict {
+		if err := checkUnusedInventoryKeys(gen, tree, templateFiles, configInventoryKeys); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	switch mode {
+	case generateModeDryRun:
+		log.Println(colorstring.Blue("Generating (dry run, no file will be written) ..."))
+	case generateModeCheck:
+		log.Println(colorstring.Blue("Checking whether the generated files are up to date (no file will be written) ..."))
+	case generateModeDiff:
+		log.Println(colorstring.Blue("Generating diff (no file will be written) ..."))
+	default:
+		log.Println(colorstring.Blue("Generating ..."))
+	}
 	fmt.Println()
-	for aTemplatePth, aOutputFilePath := range templateFiles {
-		if err := generateFileForTemplate(aTemplatePth, aOutputFilePath, ggConf); err != nil {
+	templatePths := make([]string, 0, len(templateFiles))
+	for aTemplatePth := range templateFiles {
+		templatePths = append(templatePths, aTemplatePth)
+	}
+	sort.Strings(templatePths)
+
+	statusCounts := map[generatedFileStatus]int{}
+	for _, aTemplatePth := range templatePths {
+		isOutputPathFixed := aTemplatePth == ggTemplateFilePathFlag && len(outputFilePathFlag) > 0
+		statuses, err := generateFilesForTemplate(gen, aTemplatePth, templateFiles[aTemplatePth], isOutputPathFixed, mode)
+		if err != nil {
 			return errors.WithStack(err)
 		}
+		for _, aStatus := range statuses {
+			statusCounts[aStatus]++
+		}
 	}
 	fmt.Println()
-	log.Println(colorstring.Green("[DONE] Searching for templates and generating files"))
+	switch mode {
+	case generateModeDryRun:
+		log.Println(colorstring.Greenf("[DONE] Dry run: %d new, %d changed, %d unchanged file(s), nothing was written",
+			statusCounts[generatedFileStatusNew], statusCounts[generatedFileStatusChanged], statusCounts[generatedFileStatusUnchanged]))
+	case generateModeCheck:
+		if outOfDateCount := statusCounts[generatedFileStatusNew] + statusCounts[generatedFileStatusChanged]; outOfDateCount > 0 {
+			return errors.Errorf("%d generated file(s) are missing or out of date, run gotgen generate to update them", outOfDateCount)
+		}
+		log.Println(colorstring.Green("[DONE] Every generated file is up to date"))
+	case generateModeDiff:
+		log.Println(colorstring.Greenf("[DONE] Diff: %d new, %d changed, %d unchanged file(s), nothing was written",
+			statusCounts[generatedFileStatusNew], statusCounts[generatedFileStatusChanged], statusCounts[generatedFileStatusUnchanged]))
+	default:
+		log.Println(colorstring.Green("[DONE] Searching for templates and generating files"))
+	}
 	fmt.Println()
 
 	return nil
 }
 
-func generateFileForTemplate(templatePath, generatedFilePath string, ggconf configs.Model) error {
-	fmt.Println(" * ", templatePath, " => ", generatedFilePath)
+// generatedFileStatus describes how the generated content relates to the file already on disk
+type generatedFileStatus string
 
-	templateCont, err := fileutil.ReadStringFromFile(templatePath)
+const (
+	generatedFileStatusNew       generatedFileStatus = "new"
+	generatedFileStatusChanged   generatedFileStatus = "changed"
+	generatedFileStatusUnchanged generatedFileStatus = "unchanged"
+)
+
+// statusOfGeneratedFile compares the generated content with the content of the file at generatedFilePath.
+// Returns the status and the existing content of the file (an empty string if the file does not exist).
+func statusOfGeneratedFile(generatedFilePath, generatedContent string) (generatedFileStatus, string, error) {
+	exists, err := pathutil.IsPathExists(generatedFilePath)
 	if err != nil {
-		return errors.Wrapf(err, "Failed to read template content (path: %s)", templatePath)
+		return "", "", errors.Wrapf(err, "Failed to check if file exists (path: %s)", generatedFilePath)
+	}
+	if !exists {
+		return generatedFileStatusNew, "", nil
 	}
 
-	generatedContent, err := generateContent(templateCont, ggconf.Inventory, ggconf.Delimiter.Left, ggconf.Delimiter.Right)
+	existingContent, err := fileutil.ReadStringFromFile(generatedFilePath)
 	if err != nil {
-		return errors.Wrapf(err, "Failed to generate file based on content (%s) - invalid content?", templatePath)
+		return "", "", errors.Wrapf(err, "Failed to read existing file (path: %s)", generatedFilePath)
 	}
-
-	if err := fileutil.WriteStringToFile(generatedFilePath, generatedContent); err != nil {
-		return errors.Wrapf(err, "Failed to write generated content into file (to path: %s)", generatedFilePath)
+	if existingContent == generatedContent {
+		return generatedFileStatusUnchanged, existingContent, nil
 	}
-	fmt.Println("   ", colorstring.Green("[OK]"))
-
-	return nil
+	return generatedFileStatusChanged, existingContent, nil
 }
 
-func generateContent(templateCont string, inventory map[string]interface{}, delimiterLeft, delimiterRight string) (string, error) {
-	generatedContent, err := templateutil.EvaluateTemplateStringToStringWithDelimiterAndOpts(
-		templateCont,
-		inventory, createAvailableTemplateFunctions(inventory),
-		delimiterLeft, delimiterRight,
-		[]string{"missingkey=error"})
-	if err != nil {
-		return "", errors.WithStack(err)
+// generateFilesForTemplate renders the template at templatePath, and handles the generated files according to the mode.
+// The files are generated to generatedFilePath, see generator.Generator.RenderFile,
+// or if isOutputPathFixed is true to exactly generatedFilePath, see generator.Generator.RenderFileTo.
+// Returns the status of every generated file.
+func generateFilesForTemplate(gen *generator.Generator, templatePath, generatedFilePath string, isOutputPathFixed bool, mode generateMode) ([]generatedFileStatus, error) {
+	var files []generator.File
+	if isOutputPathFixed {
+		file, err := gen.RenderFileTo(templatePath, generatedFilePath)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		files = []generator.File{file}
+	} else {
+		var err error
+		if files, err = gen.RenderFile(templatePath, generatedFilePath); err != nil {
+			return nil, errors.WithStack(err)
+		}
 	}
-	return generatedContent, nil
-}
 
-func createAvailableTemplateFunctions(inventory map[string]interface{}) template.FuncMap {
-	return template.FuncMap{
-		"var": func(key string) (interface{}, error) {
-			val, isFound := inventory[key]
-			if !isFound {
-				return "", errors.Errorf("No value found for key: %s", key)
-			}
-			return val, nil
-		},
-		"getenv": func(key string) string {
-			return os.Getenv(key)
-		},
-		"getenvRequired": func(key string) (string, error) {
-			if val := os.Getenv(key); len(val) > 0 {
-				return val, nil
-			}
-			return "", errors.Errorf("No environment variable value found for key: %s", key)
-		},
-		"yaml":             yamlFn,
-		"indentWithSpaces": indentWithSpaces,
-		"add":              add,
-		"subtract":         subtract,
-		"multiply":         multiply,
-		"divide":           divide,
-		"modulo":           modulo,
+	statuses := []generatedFileStatus{}
+	for _, aFile := range files {
+		status, err := generateFile(aFile, mode)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		statuses = append(statuses, status)
 	}
+	return statuses, nil
 }
 
-// ------------------------------------------------------------
-// Utilify functions
-// ------------------------------------------------------------
+// generateFile handles a rendered file according to the mode.
+func generateFile(file generator.File, mode generateMode) (generatedFileStatus, error) {
+	fmt.Println(" * ", file.TemplatePath, " => ", file.Path)
 
-func yamlFn(obj interface{}) (string, error) {
-	bytes, err := yaml.Marshal(obj)
+	status, existingContent, err := statusOfGeneratedFile(file.Path, file.Content)
 	if err != nil {
-		return "", errors.Errorf("Failed to generate yaml for object, error: %s", err)
-	}
-	return string(bytes), nil
-}
-
-func indentWithSpaces(indentSpaceCharCount int, s string) string {
-	if len(s) < 1 {
-		return ""
+		return "", errors.WithStack(err)
 	}
 
-	indentationString := strings.Repeat(" ", indentSpaceCharCount)
-
-	lines := strings.SplitAfter(s, "\n")
-	return indentationString + strings.Join(lines, indentationString)
-}
-
-// ------------------------------------------------------------
-// Arithmetic functions
-// Based on https://github.com/hashicorp/consul-template/blob/9ef7c22f1ec0540ef746d0ecf873353ae57c4e77/template/funcs.go#L956
-// ------------------------------------------------------------
-
-// add returns the sum of a and b.
-// Based on https://github.com/hashicorp/consul-template/blob/9ef7c22f1ec0540ef746d0ecf873353ae57c4e77/template/funcs.go#L956
-func add(b, a interface{}) (interface{}, error) {
-	av := reflect.ValueOf(a)
-	bv := reflect.ValueOf(b)
-
-	switch av.Kind() {
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		switch bv.Kind() {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			return av.Int() + bv.Int(), nil
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			return av.Int() + int64(bv.Uint()), nil
-		case reflect.Float32, reflect.Float64:
-			return float64(av.Int()) + bv.Float(), nil
-		default:
-			return nil, fmt.Errorf("add: unknown type for %q (%T)", bv, b)
-		}
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		switch bv.Kind() {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			return int64(av.Uint()) + bv.Int(), nil
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			return av.Uint() + bv.Uint(), nil
-		case reflect.Float32, reflect.Float64:
-			return float64(av.Uint()) + bv.Float(), nil
-		default:
-			return nil, fmt.Errorf("add: unknown type for %q (%T)", bv, b)
+	switch mode {
+	case generateModeDryRun:
+		fmt.Printf("    %s %s (%d bytes)\n", colorstring.Yellow("[DRY RUN]"), status, len(file.Content))
+		return status, nil
+	case generateModeCheck, generateModeDiff:
+		if status == generatedFileStatusUnchanged {
+			fmt.Println("   ", colorstring.Green("[UP TO DATE]"))
+			return status, nil
 		}
-	case reflect.Float32, reflect.Float64:
-		switch bv.Kind() {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			return av.Float() + float64(bv.Int()), nil
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			return av.Float() + float64(bv.Uint()), nil
-		case reflect.Float32, reflect.Float64:
-			return av.Float() + bv.Float(), nil
-		default:
-			return nil, fmt.Errorf("add: unknown type for %q (%T)", bv, b)
+		if mode == generateModeCheck {
+			fmt.Println("   ", colorstring.Red("[OUT OF DATE]"), status)
+		} else {
+			fmt.Println("   ", colorstring.Yellow("[DIFF]"), status)
 		}
-	default:
-		return nil, fmt.Errorf("add: unknown type for %q (%T)", av, a)
-	}
-}
 
-// subtract returns the difference of b from a.
-// Based on https://github.com/hashicorp/consul-template/blob/9ef7c22f1ec0540ef746d0ecf873353ae57c4e77/template/funcs.go#L956
-func subtract(b, a interface{}) (interface{}, error) {
-	av := reflect.ValueOf(a)
-	bv := reflect.ValueOf(b)
-
-	switch av.Kind() {
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		switch bv.Kind() {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			return av.Int() - bv.Int(), nil
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			return av.Int() - int64(bv.Uint()), nil
-		case reflect.Float32, reflect.Float64:
-			return float64(av.Int()) - bv.Float(), nil
-		default:
-			return nil, fmt.Errorf("subtract: unknown type for %q (%T)", bv, b)
-		}
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		switch bv.Kind() {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			return int64(av.Uint()) - bv.Int(), nil
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			return av.Uint() - bv.Uint(), nil
-		case reflect.Float32, reflect.Float64:
-			return float64(av.Uint()) - bv.Float(), nil
-		default:
-			return nil, fmt.Errorf("subtract: unknown type for %q (%T)", bv, b)
+		diff, err := unifiedDiff(file.Path, existingContent, file.Content, status == generatedFileStatusNew)
+		if err != nil {
+			return "", errors.WithStack(err)
 		}
-	case reflect.Float32, reflect.Float64:
-		switch bv.Kind() {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			return av.Float() - float64(bv.Int()), nil
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			return av.Float() - float64(bv.Uint()), nil
-		case reflect.Float32, reflect.Float64:
-			return av.Float() - bv.Float(), nil
-		default:
-			return nil, fmt.Errorf("subtract: unknown type for %q (%T)", bv, b)
+		if mode == generateModeDiff {
+			diff = colorizeDiff(diff)
 		}
-	default:
-		return nil, fmt.Errorf("subtract: unknown type for %q (%T)", av, a)
+		fmt.Println(diff)
+		return status, nil
 	}
-}
 
-// multiply returns the product of a and b.
-// Based on https://github.com/hashicorp/consul-template/blob/9ef7c22f1ec0540ef746d0ecf873353ae57c4e77/template/funcs.go#L956
-func multiply(b, a interface{}) (interface{}, error) {
-	av := reflect.ValueOf(a)
-	bv := reflect.ValueOf(b)
-
-	switch av.Kind() {
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		switch bv.Kind() {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			return av.Int() * bv.Int(), nil
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			return av.Int() * int64(bv.Uint()), nil
-		case reflect.Float32, reflect.Float64:
-			return float64(av.Int()) * bv.Float(), nil
-		default:
-			return nil, fmt.Errorf("multiply: unknown type for %q (%T)", bv, b)
-		}
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		switch bv.Kind() {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			return int64(av.Uint()) * bv.Int(), nil
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			return av.Uint() * bv.Uint(), nil
-		case reflect.Float32, reflect.Float64:
-			return float64(av.Uint()) * bv.Float(), nil
-		default:
-			return nil, fmt.Errorf("multiply: unknown type for %q (%T)", bv, b)
-		}
-	case reflect.Float32, reflect.Float64:
-		switch bv.Kind() {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			return av.Float() * float64(bv.Int()), nil
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			return av.Float() * float64(bv.Uint()), nil
-		case reflect.Float32, reflect.Float64:
-			return av.Float() * bv.Float(), nil
-		default:
-			return nil, fmt.Errorf("multiply: unknown type for %q (%T)", bv, b)
-		}
-	default:
-		return nil, fmt.Errorf("multiply: unknown type for %q (%T)", av, a)
+	if err := generator.WriteFile(file); err != nil {
+		return "", errors.WithStack(err)
 	}
+	fmt.Println("   ", colorstring.Green("[OK]"))
+
+	return status, nil
 }
 
-// divide returns the division of b from a.
-// Based on https://github.com/hashicorp/consul-template/blob/9ef7c22f1ec0540ef746d0ecf873353ae57c4e77/template/funcs.go#L956
-func divide(b, a interface{}) (interface{}, error) {
-	av := reflect.ValueOf(a)
-	bv := reflect.ValueOf(b)
-
-	switch av.Kind() {
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		switch bv.Kind() {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			return av.Int() / bv.Int(), nil
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			return av.Int() / int64(bv.Uint()), nil
-		case reflect.Float32, reflect.Float64:
-			return float64(av.Int()) / bv.Float(), nil
-		default:
-			return nil, fmt.Errorf("divide: unknown type for %q (%T)", bv, b)
-		}
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		switch bv.Kind() {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			return int64(av.Uint()) / bv.Int(), nil
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			return av.Uint() / bv.Uint(), nil
-		case reflect.Float32, reflect.Float64:
-			return float64(av.Uint()) / bv.Float(), nil
-		default:
-			return nil, fmt.Errorf("divide: unknown type for %q (%T)", bv, b)
-		}
-	case reflect.Float32, reflect.Float64:
-		switch bv.Kind() {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			return av.Float() / float64(bv.Int()), nil
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			return av.Float() / float64(bv.Uint()), nil
-		case reflect.Float32, reflect.Float64:
-			return av.Float() / bv.Float(), nil
-		default:
-			return nil, fmt.Errorf("divide: unknown type for %q (%T)", bv, b)
+// checkUnusedInventoryKeys returns an error if any of the inventory keys is not used by any of the templates (strict mode).
+// Every template of the tree is checked, not only the selected ones (templateFiles, see --file and --include),
+// so that a key which is only used by the templates not generated this time is not reported.
+// Only the checkedKeys are reported, so that e.g. the unrelated environment variables matching the env prefix don't fail the check.
+func checkUnusedInventoryKeys(gen *generator.Generator, tree generator.TreeOptions, templateFiles map[string]string, checkedKeys map[string]bool) error {
+	allTemplatesTree := tree
+	allTemplatesTree.Includes = nil
+	allTemplateFiles, err := gen.FindTemplates(allTemplatesTree)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for aTemplatePth, aOutputPth := range templateFiles {
+		allTemplateFiles[aTemplatePth] = aOutputPth
+	}
+	if len(allTemplateFiles) > len(templateFiles) {
+		log.Println(colorstring.Yellowf("Strict mode: checking the inventory keys used by all the %d template(s), not only by the %d selected one(s)", len(allTemplateFiles), len(templateFiles)))
+	}
+
+	templatePths := make([]string, 0, len(allTemplateFiles))
+	for aTemplatePth := range allTemplateFiles {
+		templatePths = append(templatePths, aTemplatePth)
+	}
+	unusedInventoryKeys, err := gen.UnusedInventoryKeys(templatePths)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	unusedKeys := []string{}
+	for _, aKey := range unusedInventoryKeys {
+		if checkedKeys[aKey] {
+			unusedKeys = append(unusedKeys, aKey)
 		}
-	default:
-		return nil, fmt.Errorf("divide: unknown type for %q (%T)", av, a)
 	}
+	if len(unusedKeys) > 0 {
+		return errors.Errorf("Strict mode: inventory key(s) not used by any template: %s - remove them from the inventory, or disable strict in the config", strings.Join(unusedKeys, ", "))
+	}
+	return nil
 }
 
-// modulo returns the modulo of b from a.
-// Based on https://github.com/hashicorp/consul-template/blob/9ef7c22f1ec0540ef746d0ecf873353ae57c4e77/template/funcs.go#L956
-func modulo(b, a interface{}) (interface{}, error) {
-	av := reflect.ValueOf(a)
-	bv := reflect.ValueOf(b)
-
-	switch av.Kind() {
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		switch bv.Kind() {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			return av.Int() % bv.Int(), nil
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			return av.Int() % int64(bv.Uint()), nil
-		default:
-			return nil, fmt.Errorf("modulo: unknown type for %q (%T)", bv, b)
-		}
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		switch bv.Kind() {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			return int64(av.Uint()) % bv.Int(), nil
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			return av.Uint() % bv.Uint(), nil
-		default:
-			return nil, fmt.Errorf("modulo: unknown type for %q (%T)", bv, b)
-		}
-	default:
-		return nil, fmt.Errorf("modulo: unknown type for %q (%T)", av, a)
+// generatorForConfig returns the Generator defined by the config.
+func generatorForConfig(ggConf configs.Model) (*generator.Generator, error) {
+	gen, err := generator.New(generator.OptionsFromConfig(ggConf))
+	if err != nil {
+		return nil, errors.WithStack(err)
 	}
+	return gen, nil
 }
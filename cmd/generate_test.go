@@ -1,158 +1,157 @@
 package cmd
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
-	"github.com/bitrise-io/go-utils/envutil"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/gotgen/configs"
+	"github.com/bitrise-io/gotgen/generator"
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/require"
 )
 
-func Test_generateContent(t *testing.T) {
-	t.Log("Simple string template, empty inventory - no substitution")
+func Test_statusOfGeneratedFile(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gotgen-test")
+	require.NoError(t, err)
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "existing.txt"), "existing.txt"))
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	t.Log("File does not exist")
 	{
-		genCont, err := generateContent(`Test Content`, nil, "{{", "}}")
+		status, existing, err := statusOfGeneratedFile(filepath.Join(tmpDir, "new.txt"), "content")
 		require.NoError(t, err)
-		require.Equal(t, `Test Content`, genCont)
+		require.Equal(t, generatedFileStatusNew, status)
+		require.Equal(t, "", existing)
 	}
 
-	t.Log("Missing inventory key")
+	t.Log("File exists with the same content")
 	{
-		genCont, err := generateContent(`Test {{ .KeyOne }} Content`, nil, "{{", "}}")
-		require.EqualError(t, err, `template: :1:8: executing "" at <.KeyOne>: map has no entry for key "KeyOne"`)
-		require.Equal(t, ``, genCont)
+		status, existing, err := statusOfGeneratedFile(filepath.Join(tmpDir, "existing.txt"), "existing.txt")
+		require.NoError(t, err)
+		require.Equal(t, generatedFileStatusUnchanged, status)
+		require.Equal(t, "existing.txt", existing)
 	}
 
-	t.Log("Simple substitution")
+	t.Log("File exists with different content")
 	{
-		genCont, err := generateContent(
-			`Test {{ .KeyOne }} Content`,
-			map[string]interface{}{"KeyOne": "Value 1"},
-			"{{", "}}",
-		)
+		status, existing, err := statusOfGeneratedFile(filepath.Join(tmpDir, "existing.txt"), "other content")
 		require.NoError(t, err)
-		require.Equal(t, `Test Value 1 Content`, genCont)
+		require.Equal(t, generatedFileStatusChanged, status)
+		require.Equal(t, "existing.txt", existing)
 	}
+}
 
-	t.Log("Template function: var: key found")
+func Test_checkUnusedInventoryKeys(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gotgen-test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	templatePth := filepath.Join(tmpDir, "a.txt.gg")
+	require.NoError(t, fileutil.WriteStringToFile(templatePth, "{{ .Used }}"))
+	otherTemplatePth := filepath.Join(tmpDir, "b.txt.gg")
+	require.NoError(t, fileutil.WriteStringToFile(otherTemplatePth, "{{ .UsedByOther }}"))
+	tree := generator.TreeOptions{SourceRoot: tmpDir}
+	templateFiles := map[string]string{templatePth: filepath.Join(tmpDir, "a.txt")}
+
+	t.Log("Unused keys")
 	{
-		genCont, err := generateContent(
-			`Test {{ var "KeyOne" }} Content`,
-			map[string]interface{}{"KeyOne": "Value 1"},
-			"{{", "}}",
-		)
+		gen, err := generatorForConfig(configs.Model{Inventory: map[string]interface{}{"Used": 1, "UsedByOther": 2, "Unused": 3, "Stale": 4}})
 		require.NoError(t, err)
-		require.Equal(t, `Test Value 1 Content`, genCont)
-	}
-	t.Log("Template function: var: key NOT found")
-	{
-		genCont, err := generateContent(
-			`Test {{ var "NonExistingKey" }} Content`,
-			map[string]interface{}{"KeyOne": "Value 1"},
-			"{{", "}}",
-		)
-		require.EqualError(t, err, `template: :1:8: executing "" at <var "NonExistingKey">: error calling var: No value found for key: NonExistingKey`)
-		require.Equal(t, ``, genCont)
+		err = checkUnusedInventoryKeys(gen, tree, templateFiles, map[string]bool{"Used": true, "UsedByOther": true, "Unused": true, "Stale": true})
+		require.EqualError(t, err, "Strict mode: inventory key(s) not used by any template: Stale, Unused - remove them from the inventory, or disable strict in the config")
 	}
 
-	t.Log("Template function: getenv")
+	t.Log("Only the checked keys are reported")
 	{
-		revokeFn, err := envutil.RevokableSetenv("Test_generateContent_KEY", "Test Env Value")
-		require.NoError(t, err)
-		defer func() {
-			require.NoError(t, revokeFn())
-		}()
-
-		genCont, err := generateContent(
-			`Test {{ getenv "Test_generateContent_KEY" }} Content`,
-			map[string]interface{}{"KeyOne": "Value 1"},
-			"{{", "}}",
-		)
+		gen, err := generatorForConfig(configs.Model{Inventory: map[string]interface{}{"Used": 1, "UsedByOther": 2, "Unused": 3, "FromEnv": 4}})
 		require.NoError(t, err)
-		require.Equal(t, `Test Test Env Value Content`, genCont)
+		err = checkUnusedInventoryKeys(gen, tree, templateFiles, map[string]bool{"Used": true, "UsedByOther": true, "Unused": true})
+		require.EqualError(t, err, "Strict mode: inventory key(s) not used by any template: Unused - remove them from the inventory, or disable strict in the config")
 	}
 
-	t.Log("Template function: getenvRequired: found")
+	t.Log("Keys used by the templates which are not selected are used")
 	{
-		revokeFn, err := envutil.RevokableSetenv("Test_generateContent_KEY", "Test Env Value")
+		gen, err := generatorForConfig(configs.Model{Inventory: map[string]interface{}{"Used": 1, "UsedByOther": 2}})
 		require.NoError(t, err)
-		defer func() {
-			require.NoError(t, revokeFn())
-		}()
-
-		genCont, err := generateContent(
-			`Test {{ getenvRequired "Test_generateContent_KEY" }} Content`,
-			map[string]interface{}{"KeyOne": "Value 1"},
-			"{{", "}}",
-		)
-		require.NoError(t, err)
-		require.Equal(t, `Test Test Env Value Content`, genCont)
-	}
+		require.NoError(t, checkUnusedInventoryKeys(gen, tree, templateFiles, map[string]bool{"Used": true, "UsedByOther": true}))
 
-	t.Log("Template function: getenvRequired: NOT found")
-	{
-		genCont, err := generateContent(
-			`Test {{ getenvRequired "Test_generateContent_KEY_2" }} Content`,
-			map[string]interface{}{"KeyOne": "Value 1"},
-			"{{", "}}",
-		)
-		require.EqualError(t, err, "template: :1:8: executing \"\" at <getenvRequired \"Test_generateContent_KEY_2\">: error calling getenvRequired: No environment variable value found for key: Test_generateContent_KEY_2")
-		require.Equal(t, ``, genCont)
+		tree.Includes = []string{"a.txt.gg"}
+		require.NoError(t, checkUnusedInventoryKeys(gen, tree, templateFiles, map[string]bool{"Used": true, "UsedByOther": true}))
 	}
 }
 
-func Test_indentWithSpaces(t *testing.T) {
-	require.Equal(t, "", indentWithSpaces(2, ""), "Empty string should result in an empty string")
-
-	require.Equal(t, "  a", indentWithSpaces(2, "a"))
-
-	t.Log("Multiline test")
+func Test_generate_strictWithFile(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gotgen-test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "a.txt.gg"), "{{ .Used }}"))
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "b.txt.gg"), "{{ .UsedByOther }}"))
+
+	origConfigFileName, origTemplateFilePath := gotgenConfigFileName, ggTemplateFilePathFlag
+	gotgenConfigFileName = filepath.Join(tmpDir, "gg.conf.json")
+	ggTemplateFilePathFlag = filepath.Join(tmpDir, "a.txt.gg")
+	defer func() {
+		gotgenConfigFileName, ggTemplateFilePathFlag = origConfigFileName, origTemplateFilePath
+	}()
+
+	t.Log("The keys used only by the other templates are not reported")
 	{
-		orig := `a
-b
- c`
-		expected := `  a
-  b
-   c`
-		require.Equal(t, expected, indentWithSpaces(2, orig))
-	}
+		require.NoError(t, fileutil.WriteStringToFile(gotgenConfigFileName, `{"strict": true, "source_root": "`+tmpDir+`", "inventory": {"Used": 1, "UsedByOther": 2}}`))
+		require.NoError(t, generate(&cobra.Command{}, []string{}))
 
-	t.Log("Multiline test - ending with newline at the end")
-	{
-		orig := `a
-b
- c
-`
-		expected := `  a
-  b
-   c
-  `
-		require.Equal(t, expected, indentWithSpaces(2, orig))
+		content, err := fileutil.ReadStringFromFile(filepath.Join(tmpDir, "a.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "1", content)
+		exists, err := pathutil.IsPathExists(filepath.Join(tmpDir, "b.txt"))
+		require.NoError(t, err)
+		require.False(t, exists)
 	}
-}
 
-func Test_yaml(t *testing.T) {
-	t.Log("Simple no error")
+	t.Log("The keys not used by any template are reported")
 	{
-		obj := map[string]string{"key1": "value one"}
-		expected := "key1: value one\n"
-
-		s, err := yamlFn(obj)
-		require.NoError(t, err)
-		require.Equal(t, expected, s)
+		require.NoError(t, fileutil.WriteStringToFile(gotgenConfigFileName, `{"strict": true, "source_root": "`+tmpDir+`", "inventory": {"Used": 1, "UsedByOther": 2, "Stale": 3}}`))
+		err := generate(&cobra.Command{}, []string{})
+		require.EqualError(t, err, "Strict mode: inventory key(s) not used by any template: Stale - remove them from the inventory, or disable strict in the config")
 	}
+}
 
-	t.Log("Simple error")
+func Test_generate_strictWithEnvPrefix(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gotgen-test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "a.txt.gg"), "{{ .Used }}"))
+
+	origConfigFileName, origEnvPrefix, origSetValues := gotgenConfigFileName, envPrefixFlag, setValuesFlag
+	gotgenConfigFileName = filepath.Join(tmpDir, "gg.conf.json")
+	envPrefixFlag = "GG_STRICT_TEST_"
+	setValuesFlag = []string{"FromSet=1"}
+	require.NoError(t, os.Setenv("GG_STRICT_TEST_X", "1"))
+	defer func() {
+		gotgenConfigFileName, envPrefixFlag, setValuesFlag = origConfigFileName, origEnvPrefix, origSetValues
+		require.NoError(t, os.Unsetenv("GG_STRICT_TEST_X"))
+	}()
+
+	t.Log("The keys from the env and --set are not reported")
 	{
-		// I don't know any way to make `yaml.Marshal` to return an error
+		require.NoError(t, fileutil.WriteStringToFile(gotgenConfigFileName, `{"strict": true, "source_root": "`+tmpDir+`", "inventory": {"Used": 1}}`))
+		require.NoError(t, generate(&cobra.Command{}, []string{}))
 	}
 
-	t.Log("More complex - multiline")
+	t.Log("The keys of the config are reported")
 	{
-		obj := map[string]interface{}{"key1": "value one", "key2": 2}
-		expected := "key1: value one\nkey2: 2\n"
-
-		s, err := yamlFn(obj)
-		require.NoError(t, err)
-		require.Equal(t, expected, s)
+		require.NoError(t, fileutil.WriteStringToFile(gotgenConfigFileName, `{"strict": true, "source_root": "`+tmpDir+`", "inventory": {"Used": 1, "Stale": 2}}`))
+		err := generate(&cobra.Command{}, []string{})
+		require.EqualError(t, err, "Strict mode: inventory key(s) not used by any template: Stale - remove them from the inventory, or disable strict in the config")
 	}
 }
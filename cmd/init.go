@@ -1,14 +1,26 @@
 package cmd
 
 import (
-	"encoding/json"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
 
+	"github.com/bitrise-io/go-utils/colorstring"
 	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
 	"github.com/bitrise-io/gotgen/configs"
+	"github.com/bitrise-io/gotgen/generator"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
+var (
+	scaffoldFromFlag  = ""
+	scaffoldToFlag    = ""
+	scaffoldForceFlag = false
+)
+
 // initCmd represents the init command
 var initCmd = &cobra.Command{
 	Use:   "init",
@@ -20,6 +32,10 @@ Cobra is a CLI library for Go that empowers applications.
 This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(scaffoldFromFlag) > 0 {
+			return scaffold(scaffoldFromFlag, scaffoldToFlag)
+		}
+
 		// write base config into file
 		conf := configs.Model{
 			Inventory: map[string]interface{}{
@@ -38,13 +54,14 @@ to quickly create a Cobra application.`,
 			},
 		}
 
-		jsonCont, err := json.MarshalIndent(conf, "", "  ")
+		confFormat := configs.FormatForPath(gotgenConfigFileName)
+		confCont, err := configs.Marshal(conf, confFormat)
 		if err != nil {
-			return errors.Wrap(err, "Failed to generate config JSON")
+			return errors.Wrapf(err, "Failed to generate config %s", strings.ToUpper(string(confFormat)))
 		}
 
 		confFilePth := "./" + gotgenConfigFileName
-		if err := fileutil.WriteBytesToFile(confFilePth, jsonCont); err != nil {
+		if err := fileutil.WriteBytesToFile(confFilePth, confCont); err != nil {
 			return errors.Wrapf(err, "Failed to write config into file (to path: %s)", confFilePth)
 		}
 
@@ -97,6 +114,70 @@ GG ;)
 	},
 }
 
+// scaffold creates a new directory tree in toDir from the template directory fromDir (see generator.Generator.RenderScaffold),
+// using the config (--config) in fromDir.
+func scaffold(fromDir, toDir string) error {
+	configPth := gotgenConfigFileName
+	if !filepath.IsAbs(configPth) {
+		configPth = filepath.Join(fromDir, configPth)
+	}
+
+	log.Println(colorstring.Blue("Reading GotGen config of the template directory ..."))
+	ggConf, err := readGotGenConfigFromFile(configPth)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	gen, err := generatorForConfig(ggConf)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	log.Println(colorstring.Green("[DONE] Reading GotGen config of the template directory"))
+
+	skipPths := []string{configPth, ggConf.PartialsDir, ggConf.LayoutsDir}
+	for _, anInventoryFile := range ggConf.InventoryFiles {
+		if !filepath.IsAbs(anInventoryFile) {
+			anInventoryFile = filepath.Join(filepath.Dir(configPth), anInventoryFile)
+		}
+		skipPths = append(skipPths, anInventoryFile)
+	}
+
+	log.Println(colorstring.Blue("Scaffolding ..."))
+	fmt.Println()
+	files, err := gen.RenderScaffold(fromDir, toDir, skipPths)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := writeScaffoldFiles(files, scaffoldForceFlag); err != nil {
+		return errors.WithStack(err)
+	}
+	fmt.Println()
+	log.Println(colorstring.Greenf("[DONE] Scaffolding: %d file(s) created in %s", len(files), toDir))
+
+	return nil
+}
+
+// writeScaffoldFiles writes the files, if none of them exists or if isForce is true.
+func writeScaffoldFiles(files []generator.File, isForce bool) error {
+	if !isForce {
+		for _, aFile := range files {
+			if exists, err := pathutil.IsPathExists(aFile.Path); err != nil {
+				return errors.WithStack(err)
+			} else if exists {
+				return errors.Errorf("File already exists: %s - use --force to overwrite it", aFile.Path)
+			}
+		}
+	}
+
+	for _, aFile := range files {
+		fmt.Println(" * ", aFile.TemplatePath, " => ", aFile.Path)
+		if err := generator.WriteFile(aFile); err != nil {
+			return errors.WithStack(err)
+		}
+		fmt.Println("   ", colorstring.Green("[OK]"))
+	}
+	return nil
+}
+
 func init() {
 	RootCmd.AddCommand(initCmd)
 
@@ -109,4 +190,8 @@ func init() {
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
 	// initCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	initCmd.Flags().StringVar(&scaffoldFromFlag, "from", "", "Template directory to create a new directory tree from, instead of creating an example config. Every directory and file name is rendered (e.g. {{ .ProjectName }}/main.go), the .gg files are rendered, every other file is copied as-is. The inventory is read from the config (--config) in the template directory")
+	initCmd.Flags().StringVar(&scaffoldToFlag, "to", ".", "Directory to create the new directory tree in (only with --from)")
+	initCmd.Flags().BoolVar(&scaffoldForceFlag, "force", false, "Overwrite the existing files (only with --from)")
+	addConfigFlags(initCmd)
 }
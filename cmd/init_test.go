@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/gotgen/generator"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_writeScaffoldFiles(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gotgen-test")
+	require.NoError(t, err)
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "existing.txt"), "existing.txt"))
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	t.Log("New files")
+	{
+		require.NoError(t, writeScaffoldFiles([]generator.File{
+			{Path: filepath.Join(tmpDir, "sub/new.txt"), Content: "new"},
+		}, false))
+
+		content, err := fileutil.ReadStringFromFile(filepath.Join(tmpDir, "sub/new.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "new", content)
+	}
+
+	t.Log("Existing files are only overwritten with force - nothing is written otherwise")
+	{
+		files := []generator.File{
+			{Path: filepath.Join(tmpDir, "other.txt"), Content: "other"},
+			{Path: filepath.Join(tmpDir, "existing.txt"), Content: "overwritten"},
+		}
+		err := writeScaffoldFiles(files, false)
+		require.EqualError(t, err, "File already exists: "+filepath.Join(tmpDir, "existing.txt")+" - use --force to overwrite it")
+		exists, err := pathutil.IsPathExists(filepath.Join(tmpDir, "other.txt"))
+		require.NoError(t, err)
+		require.False(t, exists)
+
+		require.NoError(t, writeScaffoldFiles(files, true))
+		content, err := fileutil.ReadStringFromFile(filepath.Join(tmpDir, "existing.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "overwritten", content)
+	}
+}
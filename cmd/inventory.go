@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/gotgen/configs"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	inventoryFilesFlag  = []string{}
+	setValuesFlag       = []string{}
+	setStringValuesFlag = []string{}
+	envPrefixFlag       = ""
+	envCoerceTypesFlag  = false
+	partialsDirFlag     = ""
+	layoutsDirFlag      = ""
+)
+
+// addConfigFlags registers the flags which modify the config (and its inventory) on the command.
+// Used by every command which reads the config with readGotGenConfig.
+func addConfigFlags(command *cobra.Command) {
+	command.Flags().StringSliceVar(&inventoryFilesFlag, "inventory", []string{}, "Inventory file (JSON, YAML or TOML) to merge into the config's inventory (can be specified multiple times, merged in order, after the config's inventory_files)")
+	command.Flags().StringArrayVar(&setValuesFlag, "set", []string{}, "Set an inventory value, in key.path=value format, e.g. Nested.KeyA.Key1=foo (can be specified multiple times). The type of the value is inferred: true/false, numbers and JSON literals are parsed, everything else is a string")
+	command.Flags().StringArrayVar(&setStringValuesFlag, "set-string", []string{}, "Same as --set, but the value is always used as a string (can be specified multiple times, applied after the --set values)")
+	command.Flags().StringVar(&envPrefixFlag, "env-prefix", "", "Import every environment variable starting with this prefix into the inventory, e.g. with GG_ the GG_NESTED__KEYA__KEY1 env var is imported as Nested.KeyA.Key1 (default: env_prefix from the config)")
+	command.Flags().BoolVar(&envCoerceTypesFlag, "env-coerce-types", false, "Infer the type of the imported environment variable values, the same way as for --set values (default: env_coerce_types from the config)")
+	command.Flags().StringVar(&partialsDirFlag, "partials-dir", "", "Directory of partial templates, which can be used in every template by name, e.g. {{ template \"header\" . }} or {{ include \"header\" . }} for the header.gg partial (default: partials_dir from the config)")
+	command.Flags().StringVar(&layoutsDirFlag, "layouts-dir", "", "Directory where the layouts referenced in the templates' front matter (layout: base) are looked up (default: layouts_dir from the config, or the template's directory)")
+}
+
+// readGotGenConfig reads the GotGen config (--config), applies the config flags, and builds its Inventory:
+// the config's inventory, merged with the inventory files, the prefixed environment variables and the --set values.
+func readGotGenConfig() (configs.Model, error) {
+	return readGotGenConfigFromFile(gotgenConfigFileName)
+}
+
+// readGotGenConfigFromFile is the same as readGotGenConfig, but it reads the config from configPth.
+func readGotGenConfigFromFile(configPth string) (configs.Model, error) {
+	ggConf, err := readGotGenConfigFileWithoutOverrides(configPth)
+	if err != nil {
+		return configs.Model{}, errors.WithStack(err)
+	}
+	return applyInventoryOverrides(ggConf)
+}
+
+// readGotGenConfigFileWithoutOverrides reads the config from configPth and applies the config flags,
+// its Inventory is the config's inventory merged with the inventory files, without the environment variables and the --set values
+// (see applyInventoryOverrides).
+func readGotGenConfigFileWithoutOverrides(configPth string) (configs.Model, error) {
+	ggConf, err := configs.ReadModelFromFile(configPth)
+	if err != nil {
+		return configs.Model{}, errors.WithStack(err)
+	}
+	// the directories of the config are relative to the config's directory, the ones specified with flags to the current directory
+	ggConf.ResolveDirs(filepath.Dir(configPth))
+	if len(partialsDirFlag) > 0 {
+		ggConf.PartialsDir = partialsDirFlag
+	}
+	if len(layoutsDirFlag) > 0 {
+		ggConf.LayoutsDir = layoutsDirFlag
+	}
+	if err := ggConf.LoadInventoryFiles(filepath.Dir(configPth), inventoryFilesFlag); err != nil {
+		return configs.Model{}, errors.WithStack(err)
+	}
+	ggConf.ResolvePluginCommands(filepath.Dir(configPth))
+	return ggConf, nil
+}
+
+// applyInventoryOverrides merges the prefixed environment variables and the --set values into the config's Inventory.
+func applyInventoryOverrides(ggConf configs.Model) (configs.Model, error) {
+	var err error
+	if len(envPrefixFlag) > 0 {
+		ggConf.EnvPrefix = envPrefixFlag
+	}
+	if len(ggConf.EnvPrefix) > 0 {
+		if ggConf.Inventory, err = configs.ApplyEnvToInventory(ggConf.Inventory, os.Environ(), ggConf.EnvPrefix, ggConf.EnvCoerceTypes || envCoerceTypesFlag); err != nil {
+			return configs.Model{}, errors.WithStack(err)
+		}
+	}
+	if ggConf.Inventory, err = applySetValues(ggConf.Inventory, setValuesFlag, setStringValuesFlag); err != nil {
+		return configs.Model{}, errors.WithStack(err)
+	}
+	return ggConf, nil
+}
+
+// applySetValues applies the --set and then the --set-string key.path=value expressions on top of the inventory,
+// and returns the result as a new map.
+// The type of the --set values is inferred (see configs.ParseInventoryValue), --set-string values are always strings.
+func applySetValues(inventory map[string]interface{}, setExpressions, setStringExpressions []string) (map[string]interface{}, error) {
+	for _, anExpression := range setExpressions {
+		keyPath, value, err := parseSetExpression(anExpression)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if inventory, err = configs.SetInventoryValue(inventory, keyPath, configs.ParseInventoryValue(value)); err != nil {
+			return nil, errors.Wrapf(err, "Failed to apply --set %s", anExpression)
+		}
+	}
+
+	for _, anExpression := range setStringExpressions {
+		keyPath, value, err := parseSetExpression(anExpression)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if inventory, err = configs.SetInventoryValue(inventory, keyPath, value); err != nil {
+			return nil, errors.Wrapf(err, "Failed to apply --set-string %s", anExpression)
+		}
+	}
+
+	return inventory, nil
+}
+
+// parseSetExpression splits a key.path=value expression into the key path and the value.
+// The value can contain additional = characters, only the first one is used as the separator.
+func parseSetExpression(expression string) (string, string, error) {
+	sepIdx := strings.Index(expression, "=")
+	if sepIdx < 1 {
+		return "", "", errors.Errorf("Invalid value: %s - has to be in the format key.path=value", expression)
+	}
+	return expression[:sepIdx], expression[sepIdx+1:], nil
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_readGotGenConfigFromFile(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gotgen-test")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "conf"), 0755))
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	configPth := filepath.Join(tmpDir, "conf", "gg.conf.json")
+	require.NoError(t, fileutil.WriteStringToFile(configPth, `{"source_root": "templates", "output_root": "../build", "partials_dir": "partials", "layouts_dir": "/abs/layouts"}`))
+
+	t.Log("The directories are relative to the config's directory")
+	{
+		ggConf, err := readGotGenConfigFromFile(configPth)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(tmpDir, "conf", "templates"), ggConf.SourceRoot)
+		require.Equal(t, filepath.Join(tmpDir, "build"), ggConf.OutputRoot)
+		require.Equal(t, filepath.Join(tmpDir, "conf", "partials"), ggConf.PartialsDir)
+		require.Equal(t, "/abs/layouts", ggConf.LayoutsDir)
+	}
+
+	t.Log("The directories of the flags are used as-is")
+	{
+		origPartialsDir := partialsDirFlag
+		partialsDirFlag = "my-partials"
+		defer func() {
+			partialsDirFlag = origPartialsDir
+		}()
+
+		ggConf, err := readGotGenConfigFromFile(configPth)
+		require.NoError(t, err)
+		require.Equal(t, "my-partials", ggConf.PartialsDir)
+	}
+}
+
+func Test_applySetValues(t *testing.T) {
+	t.Log("No expressions")
+	{
+		inventory, err := applySetValues(map[string]interface{}{"KeyOne": "one"}, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"KeyOne": "one"}, inventory)
+	}
+
+	t.Log("--set infers the type, --set-string is applied after --set")
+	{
+		inventory, err := applySetValues(
+			map[string]interface{}{"KeyOne": "one", "Nested": map[string]interface{}{"KeyB": "b"}},
+			[]string{"KeyOne=1", "Nested.KeyA.Key1=true", "Build=42"},
+			[]string{"Build=42", "Expr=a=b"},
+		)
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{
+			"KeyOne": 1,
+			"Nested": map[string]interface{}{
+				"KeyA": map[string]interface{}{"Key1": true},
+				"KeyB": "b",
+			},
+			"Build": "42",
+			"Expr":  "a=b",
+		}, inventory)
+	}
+
+	t.Log("List index")
+	{
+		inventory, err := applySetValues(
+			map[string]interface{}{"Services": []interface{}{map[string]interface{}{"Name": "a"}}},
+			[]string{"Services[0].Name=b"},
+			nil,
+		)
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"Services": []interface{}{map[string]interface{}{"Name": "b"}}}, inventory)
+	}
+
+	t.Log("Invalid expression")
+	{
+		_, err := applySetValues(nil, []string{"KeyOne"}, nil)
+		require.EqualError(t, err, "Invalid value: KeyOne - has to be in the format key.path=value")
+
+		_, err = applySetValues(nil, nil, []string{"=value"})
+		require.EqualError(t, err, "Invalid value: =value - has to be in the format key.path=value")
+	}
+
+	t.Log("Value on the path is not a map")
+	{
+		_, err := applySetValues(map[string]interface{}{"KeyOne": "one"}, []string{"KeyOne.Sub=1"}, nil)
+		require.EqualError(t, err, "Failed to apply --set KeyOne.Sub=1: Failed to set KeyOne.Sub: KeyOne is not a map (string)")
+	}
+}
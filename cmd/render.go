@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// renderCmd represents the render command
+var renderCmd = &cobra.Command{
+	Use:   "render [template file path | - (standard input, the default)]",
+	Short: "Render a single template to the standard output",
+	Long: `Render a single template to the standard output.
+
+The template is read from the specified file path, or from the standard input if no path or "-" is specified.
+Nothing else is printed to the standard output, so it can be used in shell pipelines, e.g.:
+
+  cat foo.gg | gotgen render --config x.json > foo`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         render,
+}
+
+func init() {
+	RootCmd.AddCommand(renderCmd)
+
+	addConfigFlags(renderCmd)
+}
+
+func render(cmd *cobra.Command, args []string) error {
+	// the template is read from the standard input if no path is specified
+	templatePth := "-"
+	if len(args) > 0 {
+		templatePth = args[0]
+	}
+
+	ggConf, err := readGotGenConfig()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	var templateCont string
+	if templatePth == "-" {
+		templateBytes, err := ioutil.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			return errors.Wrap(err, "Failed to read template content from the standard input")
+		}
+		templateCont = string(templateBytes)
+	} else {
+		if templateCont, err = fileutil.ReadStringFromFile(templatePth); err != nil {
+			return errors.Wrapf(err, "Failed to read template content (path: %s)", templatePth)
+		}
+	}
+
+	gen, err := generatorForConfig(ggConf)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	generatedContent, err := gen.RenderTemplate(templatePth, templateCont)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to render template (%s)", templatePth)
+	}
+
+	if _, err := fmt.Fprint(cmd.OutOrStdout(), generatedContent); err != nil {
+		return errors.Wrap(err, "Failed to write the rendered content")
+	}
+	return nil
+}
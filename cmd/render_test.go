@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_render(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gotgen-test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	confPth := filepath.Join(tmpDir, "gg.conf.json")
+	require.NoError(t, fileutil.WriteStringToFile(confPth, `{"inventory": {"KeyOne": "value one"}}`))
+	templatePth := filepath.Join(tmpDir, "template.txt.gg")
+	require.NoError(t, fileutil.WriteStringToFile(templatePth, `From file: {{ .KeyOne }}`))
+
+	origConfigFileName := gotgenConfigFileName
+	gotgenConfigFileName = confPth
+	defer func() {
+		gotgenConfigFileName = origConfigFileName
+	}()
+
+	t.Log("Template from file")
+	{
+		var out bytes.Buffer
+		command := &cobra.Command{}
+		command.SetOut(&out)
+
+		require.NoError(t, render(command, []string{templatePth}))
+		require.Equal(t, "From file: value one", out.String())
+	}
+
+	t.Log("Template from stdin")
+	{
+		var out bytes.Buffer
+		command := &cobra.Command{}
+		command.SetOut(&out)
+		command.SetIn(strings.NewReader("From stdin: {{ .KeyOne }}\n"))
+
+		require.NoError(t, render(command, []string{"-"}))
+		require.Equal(t, "From stdin: value one\n", out.String())
+	}
+
+	t.Log("Template from stdin, without a path")
+	{
+		var out bytes.Buffer
+		command := &cobra.Command{}
+		command.SetOut(&out)
+		command.SetIn(strings.NewReader("From stdin: {{ .KeyOne }}\n"))
+
+		require.NoError(t, render(command, []string{}))
+		require.Equal(t, "From stdin: value one\n", out.String())
+	}
+
+	t.Log("At most one path")
+	{
+		require.NoError(t, renderCmd.Args(renderCmd, []string{}))
+		require.Error(t, renderCmd.Args(renderCmd, []string{templatePth, templatePth}))
+	}
+
+	t.Log("Template error - nothing is written to the output")
+	{
+		var out bytes.Buffer
+		command := &cobra.Command{}
+		command.SetOut(&out)
+		command.SetIn(strings.NewReader("{{ .Missing }}"))
+
+		require.Error(t, render(command, []string{"-"}))
+		require.Equal(t, "", out.String())
+	}
+}
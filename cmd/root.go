@@ -32,14 +32,15 @@ to quickly create a Cobra application.`,
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	if err := RootCmd.Execute(); err != nil {
-		fmt.Println(err)
+		// print to stderr, so that the error never mixes with the output of the render command
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
 
 func init() {
 	cobra.OnInitialize(initConfig)
-	RootCmd.PersistentFlags().StringVar(&gotgenConfigFileName, "config", "gg.conf.json", "gotgen config file (gg.conf.json) path")
+	RootCmd.PersistentFlags().StringVar(&gotgenConfigFileName, "config", "gg.conf.json", "gotgen config file (gg.conf.json) path - .yml/.yaml and .toml config files are also supported")
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -1,13 +1,54 @@
 package configs
 
+import "path/filepath"
+
 // DelimiterModel ...
 type DelimiterModel struct {
-	Left  string `json:"left"`
-	Right string `json:"right"`
+	Left  string `json:"left" yaml:"left" toml:"left"`
+	Right string `json:"right" yaml:"right" toml:"right"`
 }
 
 // Model ...
 type Model struct {
-	Inventory map[string]interface{} `json:"inventory"`
-	Delimiter DelimiterModel         `json:"delimiter"`
+	Inventory map[string]interface{} `json:"inventory" yaml:"inventory" toml:"inventory"`
+	Delimiter DelimiterModel         `json:"delimiter" yaml:"delimiter" toml:"delimiter"`
+	// InventoryFiles are merged into Inventory, in order. See MergeInventory for the merge rules.
+	// Relative paths are relative to the config file's directory.
+	InventoryFiles []string `json:"inventory_files,omitempty" yaml:"inventory_files,omitempty" toml:"inventory_files,omitempty"`
+	// EnvPrefix if specified every environment variable starting with this prefix is imported into the Inventory.
+	// See ApplyEnvToInventory for how the variable names are mapped to inventory keys.
+	EnvPrefix string `json:"env_prefix,omitempty" yaml:"env_prefix,omitempty" toml:"env_prefix,omitempty"`
+	// EnvCoerceTypes if true the type of the imported environment variable values is inferred (see ParseInventoryValue),
+	// otherwise the values are imported as strings.
+	EnvCoerceTypes bool `json:"env_coerce_types,omitempty" yaml:"env_coerce_types,omitempty" toml:"env_coerce_types,omitempty"`
+	// SourceRoot is the directory where the .gg templates are searched for. Defaults to the current directory.
+	// Relative paths are relative to the config file's directory, like the paths of OutputRoot, PartialsDir and LayoutsDir.
+	SourceRoot string `json:"source_root,omitempty" yaml:"source_root,omitempty" toml:"source_root,omitempty"`
+	// OutputRoot is the directory where the generated files are written, with the same relative layout
+	// the templates have in SourceRoot. If not specified the files are generated next to their templates.
+	OutputRoot string `json:"output_root,omitempty" yaml:"output_root,omitempty" toml:"output_root,omitempty"`
+	// PartialsDir is a directory of partial templates, which are parsed as named templates into every template.
+	// The files in this directory are never generated on their own.
+	PartialsDir string `json:"partials_dir,omitempty" yaml:"partials_dir,omitempty" toml:"partials_dir,omitempty"`
+	// LayoutsDir is the directory where the layouts referenced in the templates' front matter are looked up.
+	// If not specified the layouts are looked up next to the template. The files in this directory are never generated on their own.
+	LayoutsDir string `json:"layouts_dir,omitempty" yaml:"layouts_dir,omitempty" toml:"layouts_dir,omitempty"`
+	// MissingKey defines what happens if a template uses a key which is not in the inventory, defaults to MissingKeyError.
+	// It can be overridden for a single template in its front matter.
+	MissingKey MissingKeyMode `json:"missing_key,omitempty" yaml:"missing_key,omitempty" toml:"missing_key,omitempty"`
+	// Strict if true generating fails if an inventory key is not used by any of the generated templates,
+	// so that stale inventory entries are caught.
+	Strict bool `json:"strict,omitempty" yaml:"strict,omitempty" toml:"strict,omitempty"`
+	// Plugins are template functions implemented by external executables, by function name.
+	Plugins map[string]PluginModel `json:"plugins,omitempty" yaml:"plugins,omitempty" toml:"plugins,omitempty"`
+}
+
+// ResolveDirs makes the relative SourceRoot, OutputRoot, PartialsDir and LayoutsDir paths relative to the config file's directory (configDir),
+// like the InventoryFiles and the plugin commands.
+func (model *Model) ResolveDirs(configDir string) {
+	for _, aDir := range []*string{&model.SourceRoot, &model.OutputRoot, &model.PartialsDir, &model.LayoutsDir} {
+		if len(*aDir) > 0 && !filepath.IsAbs(*aDir) {
+			*aDir = filepath.Join(configDir, *aDir)
+		}
+	}
 }
@@ -0,0 +1,18 @@
+package configs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestModel_ResolveDirs(t *testing.T) {
+	model := Model{SourceRoot: "templates", OutputRoot: "../build", PartialsDir: "/abs/partials"}
+	model.ResolveDirs("path/to/config")
+
+	require.Equal(t, Model{
+		SourceRoot:  "path/to/config/templates",
+		OutputRoot:  "path/to/build",
+		PartialsDir: "/abs/partials",
+	}, model)
+}
@@ -0,0 +1,84 @@
+package configs
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// envKeyPathSeparator separates the nested keys in the environment variable names,
+// e.g. GG_NESTED__KEYA__KEY1 => Nested.KeyA.Key1
+const envKeyPathSeparator = "__"
+
+// ApplyEnvToInventory sets every environment variable which starts with prefix in the inventory,
+// and returns the result as a new map. The input inventory is not modified.
+//
+// environ is in the format os.Environ returns. The variable name without the prefix is split at every double underscore
+// into nested keys, and every key is matched against the existing keys of the inventory case insensitively,
+// ignoring single underscores (e.g. NESTED__KEYA__KEY1 => Nested.KeyA.Key1, BUILD_NUMBER => BuildNumber).
+// Keys which don't match an existing key are used as-is.
+//
+// If coerceTypes is true the values are parsed with ParseInventoryValue, otherwise they are used as strings.
+func ApplyEnvToInventory(inventory map[string]interface{}, environ []string, prefix string, coerceTypes bool) (map[string]interface{}, error) {
+	if prefix == "" {
+		return nil, errors.New("No environment variable prefix specified")
+	}
+
+	envs := append([]string{}, environ...)
+	sort.Strings(envs)
+
+	for _, anEnv := range envs {
+		sepIdx := strings.Index(anEnv, "=")
+		if sepIdx < 0 {
+			continue
+		}
+		name, value := anEnv[:sepIdx], anEnv[sepIdx+1:]
+		if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+			continue
+		}
+
+		keys := strings.Split(strings.TrimPrefix(name, prefix), envKeyPathSeparator)
+		keyPath, err := resolveEnvKeyPath(inventory, keys)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid environment variable name: %s", name)
+		}
+
+		var typedValue interface{} = value
+		if coerceTypes {
+			typedValue = ParseInventoryValue(value)
+		}
+
+		if inventory, err = SetInventoryValue(inventory, keyPath, typedValue); err != nil {
+			return nil, errors.Wrapf(err, "Failed to apply environment variable %s", name)
+		}
+	}
+
+	return inventory, nil
+}
+
+func resolveEnvKeyPath(inventory map[string]interface{}, keys []string) (string, error) {
+	resolvedKeys := make([]string, len(keys))
+	current := inventory
+	for idx, aKey := range keys {
+		if aKey == "" {
+			return "", errors.Errorf("empty key at position %d", idx+1)
+		}
+
+		resolvedKeys[idx] = aKey
+		for existingKey := range current {
+			if normalizedEnvKey(existingKey) == normalizedEnvKey(aKey) {
+				resolvedKeys[idx] = existingKey
+				break
+			}
+		}
+
+		nested, _ := current[resolvedKeys[idx]].(map[string]interface{})
+		current = nested
+	}
+	return strings.Join(resolvedKeys, "."), nil
+}
+
+func normalizedEnvKey(key string) string {
+	return strings.ToLower(strings.Replace(key, "_", "", -1))
+}
@@ -0,0 +1,71 @@
+package configs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyEnvToInventory(t *testing.T) {
+	inventory := map[string]interface{}{
+		"KeyOne": "one",
+		"Nested": map[string]interface{}{
+			"KeyA": map[string]interface{}{
+				"Key1": "KeyA-Key1 value",
+			},
+		},
+		"BuildNumber": 0,
+	}
+	environ := []string{
+		"PATH=/usr/bin",
+		"GG_NESTED__KEYA__KEY1=from env",
+		"GG_BUILD_NUMBER=42",
+		"GG_NEW_KEY=true",
+		"GG_=ignored",
+		"GGX_KEYONE=ignored",
+	}
+
+	t.Log("Keys are matched case insensitively, values are strings")
+	{
+		updated, err := ApplyEnvToInventory(inventory, environ, "GG_", false)
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{
+			"KeyOne": "one",
+			"Nested": map[string]interface{}{
+				"KeyA": map[string]interface{}{
+					"Key1": "from env",
+				},
+			},
+			"BuildNumber": "42",
+			"NEW_KEY":     "true",
+		}, updated)
+
+		// the input is not modified
+		require.Equal(t, 0, inventory["BuildNumber"])
+	}
+
+	t.Log("Type coercion")
+	{
+		updated, err := ApplyEnvToInventory(inventory, environ, "GG_", true)
+		require.NoError(t, err)
+		require.Equal(t, 42, updated["BuildNumber"])
+		require.Equal(t, true, updated["NEW_KEY"])
+		require.Equal(t, "from env", updated["Nested"].(map[string]interface{})["KeyA"].(map[string]interface{})["Key1"])
+	}
+
+	t.Log("New nested keys")
+	{
+		updated, err := ApplyEnvToInventory(nil, []string{"GG_A__B=c"}, "GG_", false)
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"A": map[string]interface{}{"B": "c"}}, updated)
+	}
+
+	t.Log("Invalid names")
+	{
+		_, err := ApplyEnvToInventory(nil, []string{"GG_A____B=c"}, "GG_", false)
+		require.EqualError(t, err, "Invalid environment variable name: GG_A____B: empty key at position 2")
+
+		_, err = ApplyEnvToInventory(inventory, []string{"GG_KEYONE__SUB=c"}, "GG_", false)
+		require.EqualError(t, err, "Failed to apply environment variable GG_KEYONE__SUB: Failed to set KeyOne.SUB: KeyOne is not a map (string)")
+	}
+}
@@ -0,0 +1,134 @@
+package configs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Format ...
+type Format string
+
+const (
+	// FormatJSON ...
+	FormatJSON Format = "json"
+	// FormatYAML ...
+	FormatYAML Format = "yaml"
+	// FormatTOML ...
+	FormatTOML Format = "toml"
+)
+
+// FormatForPath returns the Format of the file based on its extension:
+// .yml and .yaml files are YAML, .toml files are TOML, everything else is treated as JSON.
+func FormatForPath(pth string) Format {
+	switch strings.ToLower(filepath.Ext(pth)) {
+	case ".yml", ".yaml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	default:
+		return FormatJSON
+	}
+}
+
+// Unmarshal decodes the content in the specified format into v.
+//
+// Note: YAML maps are decoded as map[interface{}]interface{},
+// use NormalizeValue on the decoded values to convert them into map[string]interface{}.
+func Unmarshal(content []byte, format Format, v interface{}) error {
+	switch format {
+	case FormatYAML:
+		return yaml.Unmarshal(content, v)
+	case FormatTOML:
+		return toml.Unmarshal(content, v)
+	case FormatJSON:
+		return json.Unmarshal(content, v)
+	}
+	return errors.Errorf("Unsupported format: %s", format)
+}
+
+// Marshal encodes v in the specified format.
+func Marshal(v interface{}, format Format) ([]byte, error) {
+	switch format {
+	case FormatYAML:
+		return yaml.Marshal(v)
+	case FormatTOML:
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(v); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case FormatJSON:
+		return json.MarshalIndent(v, "", "  ")
+	}
+	return nil, errors.Errorf("Unsupported format: %s", format)
+}
+
+// ReadModelFromFile reads the GotGen config from the file,
+// in the format determined by the file's extension (see FormatForPath).
+func ReadModelFromFile(pth string) (Model, error) {
+	content, err := fileutil.ReadBytesFromFile(pth)
+	if err != nil {
+		return Model{}, errors.Wrapf(err, "Failed to read GotGen config (%s) file", pth)
+	}
+
+	format := FormatForPath(pth)
+	model := Model{}
+	if err := Unmarshal(content, format, &model); err != nil {
+		return Model{}, errors.Wrapf(err, "Failed to parse GotGen config (%s)", strings.ToUpper(string(format)))
+	}
+	model.Inventory = NormalizeMap(model.Inventory)
+
+	return model, nil
+}
+
+// NormalizeValue converts the decoded value into the types the JSON decoder would produce for maps and lists:
+// every map (e.g. map[interface{}]interface{} from YAML) is converted into a map[string]interface{},
+// and every list (e.g. []map[string]interface{} from TOML) is converted into a []interface{}, recursively.
+//
+// This way the values can be accessed the same way in the templates (e.g. .Nested.KeyA),
+// regardless of which format they were read from.
+func NormalizeValue(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case map[interface{}]interface{}:
+		normalized := make(map[string]interface{}, len(typedValue))
+		for key, val := range typedValue {
+			normalized[fmt.Sprintf("%v", key)] = NormalizeValue(val)
+		}
+		return normalized
+	case map[string]interface{}:
+		return NormalizeMap(typedValue)
+	case []map[string]interface{}:
+		normalized := make([]interface{}, len(typedValue))
+		for idx, val := range typedValue {
+			normalized[idx] = NormalizeMap(val)
+		}
+		return normalized
+	case []interface{}:
+		normalized := make([]interface{}, len(typedValue))
+		for idx, val := range typedValue {
+			normalized[idx] = NormalizeValue(val)
+		}
+		return normalized
+	}
+	return value
+}
+
+// NormalizeMap calls NormalizeValue on every value of the map.
+func NormalizeMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	normalized := make(map[string]interface{}, len(m))
+	for key, val := range m {
+		normalized[key] = NormalizeValue(val)
+	}
+	return normalized
+}
@@ -0,0 +1,104 @@
+package configs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatForPath(t *testing.T) {
+	require.Equal(t, FormatJSON, FormatForPath("gg.conf.json"))
+	require.Equal(t, FormatYAML, FormatForPath("gg.conf.yml"))
+	require.Equal(t, FormatYAML, FormatForPath("path/to/gg.conf.YAML"))
+	require.Equal(t, FormatTOML, FormatForPath("gg.conf.toml"))
+	require.Equal(t, FormatJSON, FormatForPath("gg.conf"), "Unknown extension should default to JSON")
+}
+
+func TestUnmarshal(t *testing.T) {
+	expected := Model{
+		Inventory: map[string]interface{}{
+			"KeyOne": "value for key one",
+			"Nested": map[string]interface{}{
+				"KeyA": map[string]interface{}{
+					"Key1": "KeyA-Key1 value",
+				},
+			},
+			"List": []interface{}{
+				map[string]interface{}{"Name": "first"},
+			},
+		},
+		Delimiter: DelimiterModel{Left: "[[", Right: "]]"},
+	}
+
+	t.Log("JSON")
+	{
+		model := Model{}
+		require.NoError(t, Unmarshal([]byte(`{
+  "inventory": {
+    "KeyOne": "value for key one",
+    "Nested": {"KeyA": {"Key1": "KeyA-Key1 value"}},
+    "List": [{"Name": "first"}]
+  },
+  "delimiter": {"left": "[[", "right": "]]"}
+}`), FormatJSON, &model))
+		model.Inventory = NormalizeMap(model.Inventory)
+		require.Equal(t, expected, model)
+	}
+
+	t.Log("YAML - maps are normalized")
+	{
+		model := Model{}
+		require.NoError(t, Unmarshal([]byte(`# comments are allowed
+inventory:
+  KeyOne: value for key one
+  Nested:
+    KeyA:
+      Key1: KeyA-Key1 value
+  List:
+  - Name: first
+delimiter:
+  left: "[["
+  right: "]]"
+`), FormatYAML, &model))
+		model.Inventory = NormalizeMap(model.Inventory)
+		require.Equal(t, expected, model)
+	}
+
+	t.Log("TOML - array of tables is normalized")
+	{
+		model := Model{}
+		require.NoError(t, Unmarshal([]byte(`# comments are allowed
+[inventory]
+KeyOne = "value for key one"
+
+[inventory.Nested.KeyA]
+Key1 = "KeyA-Key1 value"
+
+[[inventory.List]]
+Name = "first"
+
+[delimiter]
+left = "[["
+right = "]]"
+`), FormatTOML, &model))
+		model.Inventory = NormalizeMap(model.Inventory)
+		require.Equal(t, expected, model)
+	}
+
+	t.Log("Invalid content")
+	{
+		model := Model{}
+		require.Error(t, Unmarshal([]byte(`{`), FormatJSON, &model))
+		require.Error(t, Unmarshal([]byte("a: [\n"), FormatYAML, &model))
+		require.Error(t, Unmarshal([]byte(`a = `), FormatTOML, &model))
+	}
+}
+
+func TestNormalizeValue(t *testing.T) {
+	require.Equal(t, "str", NormalizeValue("str"))
+	require.Equal(t, 1, NormalizeValue(1))
+	require.Equal(t,
+		map[string]interface{}{"1": []interface{}{map[string]interface{}{"b": true}}},
+		NormalizeValue(map[interface{}]interface{}{1: []interface{}{map[interface{}]interface{}{"b": true}}}),
+	)
+}
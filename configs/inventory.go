@@ -0,0 +1,359 @@
+package configs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/pkg/errors"
+)
+
+// ReadInventoryFromFile reads an inventory file, in the format determined by the file's extension (see FormatForPath).
+// The file's root has to be an object / map, which is used as the inventory.
+func ReadInventoryFromFile(pth string) (map[string]interface{}, error) {
+	content, err := fileutil.ReadBytesFromFile(pth)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read inventory (%s) file", pth)
+	}
+
+	format := FormatForPath(pth)
+	inventory := map[string]interface{}{}
+	if err := Unmarshal(content, format, &inventory); err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse inventory (%s) file (%s)", pth, strings.ToUpper(string(format)))
+	}
+	return NormalizeMap(inventory), nil
+}
+
+// LoadInventoryFiles merges the model's InventoryFiles and then the additional inventory files
+// into the model's Inventory, in order. See MergeInventory for the merge rules.
+//
+// Relative InventoryFiles paths are resolved relative to configDir (the directory of the config file),
+// additionalInventoryFiles are used as-is.
+func (model *Model) LoadInventoryFiles(configDir string, additionalInventoryFiles []string) error {
+	inventoryFiles := []string{}
+	for _, aPth := range model.InventoryFiles {
+		if !filepath.IsAbs(aPth) {
+			aPth = filepath.Join(configDir, aPth)
+		}
+		inventoryFiles = append(inventoryFiles, aPth)
+	}
+	inventoryFiles = append(inventoryFiles, additionalInventoryFiles...)
+
+	for _, aPth := range inventoryFiles {
+		inventory, err := ReadInventoryFromFile(aPth)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		model.Inventory = MergeInventory(model.Inventory, inventory)
+	}
+	return nil
+}
+
+// MergeInventory deep-merges override into base and returns the result as a new map.
+// Neither base nor override is modified.
+//
+// The merge rules:
+//
+//   - If both base and override have a map for the same key, the two maps are merged recursively, with the same rules.
+//   - Otherwise the value in override replaces the value in base. This includes lists too:
+//     lists are not merged or concatenated, the list in override replaces the one in base.
+//   - If the value in override is null the key is removed from the result.
+func MergeInventory(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for key, val := range base {
+		merged[key] = val
+	}
+
+	for key, overrideVal := range override {
+		if overrideVal == nil {
+			delete(merged, key)
+			continue
+		}
+
+		baseMap, isBaseMap := merged[key].(map[string]interface{})
+		overrideMap, isOverrideMap := overrideVal.(map[string]interface{})
+		if isBaseMap && isOverrideMap {
+			merged[key] = MergeInventory(baseMap, overrideMap)
+			continue
+		}
+		merged[key] = overrideVal
+	}
+
+	return merged
+}
+
+// CopyInventory returns a deep copy of the inventory: the nested maps (map[string]interface{} and map[interface{}]interface{})
+// and lists ([]interface{}) are copied too, so that modifying the copy doesn't modify the inventory.
+func CopyInventory(inventory map[string]interface{}) map[string]interface{} {
+	if inventory == nil {
+		return nil
+	}
+	return CopyValue(inventory).(map[string]interface{})
+}
+
+// CopyValue returns a deep copy of the value, see CopyInventory. Every other type of value is returned as-is.
+func CopyValue(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(typedValue))
+		for key, val := range typedValue {
+			copied[key] = CopyValue(val)
+		}
+		return copied
+	case map[interface{}]interface{}:
+		copied := make(map[interface{}]interface{}, len(typedValue))
+		for key, val := range typedValue {
+			copied[key] = CopyValue(val)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(typedValue))
+		for idx, val := range typedValue {
+			copied[idx] = CopyValue(val)
+		}
+		return copied
+	}
+	return value
+}
+
+// SetInventoryValue sets the value at the keyPath (e.g. Nested.KeyA.Key1 or Services[0].Name, see ValueAtPath)
+// and returns the result as a new map. The missing maps on the path are created. The input inventory is not modified.
+//
+// Returns an error if a value on the path exists but it is not a map, or if a list index on the path is not an existing item of a list.
+func SetInventoryValue(inventory map[string]interface{}, keyPath string, value interface{}) (map[string]interface{}, error) {
+	segments, err := parseKeyPath(keyPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if segments[0].IsIndex {
+		return nil, errors.Errorf("Failed to set %s: the root is not a list", keyPath)
+	}
+
+	updated, err := setValueAtSegments(inventory, keyPath, "", segments, value)
+	if err != nil {
+		return nil, err
+	}
+	return updated.(map[string]interface{}), nil
+}
+
+// setValueAtSegments sets the value at the segments in current (which is at parentPath in the inventory),
+// and returns the updated copy of current.
+func setValueAtSegments(current interface{}, keyPath, parentPath string, segments []keyPathSegment, value interface{}) (interface{}, error) {
+	segment := segments[0]
+
+	if segment.IsIndex {
+		currentPath := fmt.Sprintf("%s[%d]", parentPath, segment.Index)
+		list, isList := current.([]interface{})
+		if !isList {
+			return nil, errors.Errorf("Failed to set %s: %s is not a list (%T)", keyPath, parentPath, current)
+		}
+		if segment.Index >= len(list) {
+			return nil, errors.Errorf("Failed to set %s: index out of range: %s (length: %d)", keyPath, currentPath, len(list))
+		}
+
+		updated := append([]interface{}{}, list...)
+		if len(segments) == 1 {
+			updated[segment.Index] = value
+			return updated, nil
+		}
+		updatedItem, err := setValueAtSegments(list[segment.Index], keyPath, currentPath, segments[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		updated[segment.Index] = updatedItem
+		return updated, nil
+	}
+
+	currentPath := segment.Key
+	if parentPath != "" {
+		currentPath = parentPath + "." + segment.Key
+	}
+	var currentMap map[string]interface{}
+	if current != nil {
+		typedCurrent, isMap := current.(map[string]interface{})
+		if !isMap {
+			return nil, errors.Errorf("Failed to set %s: %s is not a map (%T)", keyPath, parentPath, current)
+		}
+		currentMap = typedCurrent
+	}
+
+	updated := make(map[string]interface{}, len(currentMap)+1)
+	for key, val := range currentMap {
+		updated[key] = val
+	}
+	if len(segments) == 1 {
+		updated[segment.Key] = value
+		return updated, nil
+	}
+	updatedNested, err := setValueAtSegments(updated[segment.Key], keyPath, currentPath, segments[1:], value)
+	if err != nil {
+		return nil, err
+	}
+	updated[segment.Key] = updatedNested
+	return updated, nil
+}
+
+// ParseInventoryValue infers the type of the value from its string representation:
+// "true" and "false" are parsed as bool, integer numbers as int, other numbers as float64,
+// and other JSON literals (objects, arrays, quoted strings and null) as the JSON value.
+// If the value is none of these it's returned as a string.
+func ParseInventoryValue(s string) interface{} {
+	decoder := json.NewDecoder(strings.NewReader(s))
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return s
+	}
+	// only accept it if the whole input was a single JSON value
+	if _, err := decoder.Token(); err != io.EOF {
+		return s
+	}
+
+	if f, isNumber := value.(float64); isNumber {
+		if i, err := strconv.ParseInt(strings.TrimSpace(s), 10, 0); err == nil {
+			return int(i)
+		}
+		return f
+	}
+	return NormalizeValue(value)
+}
+
+// InventoryValue returns the value at the keyPath (see ValueAtPath), e.g. Nested.KeyA.Key1 or Services[0].Name.
+// Returns an error which names the first missing key if there's no value at the path.
+func InventoryValue(inventory map[string]interface{}, keyPath string) (interface{}, error) {
+	return ValueAtPath(inventory, keyPath)
+}
+
+// keyPathSegment is either a map key or a list index of a key path
+type keyPathSegment struct {
+	Key     string
+	Index   int
+	IsIndex bool
+}
+
+// parseKeyPath splits the keyPath into map keys (separated by dots) and list indexes (in brackets),
+// e.g. Services[0].Ports[1] => Services, [0], Ports, [1].
+func parseKeyPath(keyPath string) ([]keyPathSegment, error) {
+	segments := []keyPathSegment{}
+	for idx := 0; idx < len(keyPath); {
+		if keyPath[idx] == '[' {
+			closeIdx := strings.IndexByte(keyPath[idx:], ']')
+			if closeIdx < 0 {
+				return nil, errors.Errorf("Invalid key path: %s - missing ]", keyPath)
+			}
+			index, err := strconv.Atoi(keyPath[idx+1 : idx+closeIdx])
+			if err != nil || index < 0 {
+				return nil, errors.Errorf("Invalid key path: %s - invalid index: %s", keyPath, keyPath[idx:idx+closeIdx+1])
+			}
+			segments = append(segments, keyPathSegment{Index: index, IsIndex: true})
+			idx += closeIdx + 1
+		} else {
+			keyEnd := strings.IndexAny(keyPath[idx:], ".[")
+			if keyEnd < 0 {
+				keyEnd = len(keyPath) - idx
+			}
+			if keyEnd == 0 {
+				return nil, errors.Errorf("Invalid key path: %s - empty key", keyPath)
+			}
+			segments = append(segments, keyPathSegment{Key: keyPath[idx : idx+keyEnd]})
+			idx += keyEnd
+		}
+
+		if idx < len(keyPath) && keyPath[idx] == '.' {
+			idx++
+			if idx == len(keyPath) || keyPath[idx] == '[' {
+				return nil, errors.Errorf("Invalid key path: %s - empty key", keyPath)
+			}
+		} else if idx < len(keyPath) && keyPath[idx] != '[' {
+			return nil, errors.Errorf("Invalid key path: %s - unexpected character after ]: %c", keyPath, keyPath[idx])
+		}
+	}
+	if len(segments) == 0 {
+		return nil, errors.New("Invalid key path: empty")
+	}
+	return segments, nil
+}
+
+// ValueAtPath returns the value at the keyPath in value, which can contain dot separated map keys
+// and list indexes in brackets, e.g. Nested.KeyA.Key1 or Services[0].Ports[1].
+// Both the inventory's maps (map[string]interface{}) and YAML maps (map[interface{}]interface{}) are supported.
+// Returns an error which names the exact missing key or index if there's no value at the path,
+// e.g. "No value found for key: Services[2].Name - index out of range: Services[2] (length: 2)".
+func ValueAtPath(value interface{}, keyPath string) (interface{}, error) {
+	segments, err := parseKeyPath(keyPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return valueAtSegments(value, keyPath, segments)
+}
+
+// HasValueAtPath reports whether there is a value at the keyPath in value (see ValueAtPath).
+// Only returns an error if the keyPath itself is invalid.
+func HasValueAtPath(value interface{}, keyPath string) (bool, error) {
+	segments, err := parseKeyPath(keyPath)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	_, err = valueAtSegments(value, keyPath, segments)
+	return err == nil, nil
+}
+
+func valueAtSegments(value interface{}, keyPath string, segments []keyPathSegment) (interface{}, error) {
+	current := value
+	currentPath := ""
+	for _, aSegment := range segments {
+		parentPath := currentPath
+
+		if aSegment.IsIndex {
+			currentPath = fmt.Sprintf("%s[%d]", currentPath, aSegment.Index)
+
+			listValue := reflect.ValueOf(current)
+			if listValue.Kind() != reflect.Slice && listValue.Kind() != reflect.Array {
+				return nil, errors.Errorf("No value found for key: %s - %s is not a list (%T)", keyPath, pathOrRoot(parentPath), current)
+			}
+			if aSegment.Index >= listValue.Len() {
+				return nil, errors.Errorf("No value found for key: %s - index out of range: %s (length: %d)", keyPath, currentPath, listValue.Len())
+			}
+			current = listValue.Index(aSegment.Index).Interface()
+			continue
+		}
+
+		if currentPath == "" {
+			currentPath = aSegment.Key
+		} else {
+			currentPath += "." + aSegment.Key
+		}
+
+		var val interface{}
+		var isFound bool
+		switch typedCurrent := current.(type) {
+		case map[string]interface{}:
+			val, isFound = typedCurrent[aSegment.Key]
+		case map[interface{}]interface{}:
+			val, isFound = typedCurrent[aSegment.Key]
+		default:
+			return nil, errors.Errorf("No value found for key: %s - %s is not a map (%T)", keyPath, pathOrRoot(parentPath), current)
+		}
+		if !isFound {
+			if currentPath == keyPath {
+				// the whole key path is missing, e.g. a top level key
+				return nil, errors.Errorf("No value found for key: %s", keyPath)
+			}
+			return nil, errors.Errorf("No value found for key: %s - missing key: %s", keyPath, currentPath)
+		}
+		current = val
+	}
+	return current, nil
+}
+
+func pathOrRoot(keyPath string) string {
+	if keyPath == "" {
+		return "the root"
+	}
+	return keyPath
+}
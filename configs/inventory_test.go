@@ -0,0 +1,314 @@
+package configs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeInventory(t *testing.T) {
+	t.Log("Empty inputs")
+	{
+		require.Equal(t, map[string]interface{}{}, MergeInventory(nil, nil))
+	}
+
+	t.Log("Maps are merged recursively, everything else is replaced")
+	{
+		base := map[string]interface{}{
+			"KeyOne": "base one",
+			"KeyTwo": "base two",
+			"List":   []interface{}{"a", "b"},
+			"Nested": map[string]interface{}{
+				"KeyA": map[string]interface{}{
+					"Key1": "base A1",
+					"Key2": "base A2",
+				},
+				"KeyB": "base B",
+			},
+			"ReplacedByScalar": map[string]interface{}{"Key": "val"},
+		}
+		override := map[string]interface{}{
+			"KeyTwo": "override two",
+			"List":   []interface{}{"c"},
+			"Nested": map[string]interface{}{
+				"KeyA": map[string]interface{}{
+					"Key2": "override A2",
+					"Key3": "override A3",
+				},
+			},
+			"ReplacedByScalar": "scalar",
+			"New":              true,
+		}
+
+		merged := MergeInventory(base, override)
+		require.Equal(t, map[string]interface{}{
+			"KeyOne": "base one",
+			"KeyTwo": "override two",
+			"List":   []interface{}{"c"},
+			"Nested": map[string]interface{}{
+				"KeyA": map[string]interface{}{
+					"Key1": "base A1",
+					"Key2": "override A2",
+					"Key3": "override A3",
+				},
+				"KeyB": "base B",
+			},
+			"ReplacedByScalar": "scalar",
+			"New":              true,
+		}, merged)
+
+		// inputs are not modified
+		require.Equal(t, "base two", base["KeyTwo"])
+		require.Equal(t, 2, len(base["Nested"].(map[string]interface{})["KeyA"].(map[string]interface{})))
+	}
+
+	t.Log("null removes the key")
+	{
+		merged := MergeInventory(
+			map[string]interface{}{"KeyOne": "one", "Nested": map[string]interface{}{"KeyA": "a", "KeyB": "b"}},
+			map[string]interface{}{"KeyOne": nil, "Nested": map[string]interface{}{"KeyA": nil}},
+		)
+		require.Equal(t, map[string]interface{}{"Nested": map[string]interface{}{"KeyB": "b"}}, merged)
+	}
+}
+
+func TestModel_LoadInventoryFiles(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gotgen-test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "base.yml"), `Env: base
+Nested:
+  KeyA: base A
+  KeyB: base B
+`))
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "staging.json"), `{"Env": "staging", "Nested": {"KeyA": "staging A"}}`))
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "local.toml"), `Env = "local"`))
+
+	t.Log("Config inventory, then config inventory files, then additional files")
+	{
+		model := Model{
+			Inventory:      map[string]interface{}{"Env": "inline", "Inline": true},
+			InventoryFiles: []string{"base.yml", "staging.json"},
+		}
+		require.NoError(t, model.LoadInventoryFiles(tmpDir, []string{filepath.Join(tmpDir, "local.toml")}))
+		require.Equal(t, map[string]interface{}{
+			"Env":    "local",
+			"Inline": true,
+			"Nested": map[string]interface{}{
+				"KeyA": "staging A",
+				"KeyB": "base B",
+			},
+		}, model.Inventory)
+	}
+
+	t.Log("Missing inventory file")
+	{
+		model := Model{InventoryFiles: []string{"missing.json"}}
+		require.Error(t, model.LoadInventoryFiles(tmpDir, nil))
+	}
+}
+
+func TestCopyInventory(t *testing.T) {
+	require.Nil(t, CopyInventory(nil))
+
+	inventory := map[string]interface{}{
+		"Nested": map[string]interface{}{"Key": "value"},
+		"YAML":   map[interface{}]interface{}{"Key": "value"},
+		"List":   []interface{}{map[string]interface{}{"Key": "value"}},
+		"Str":    "str",
+	}
+	copied := CopyInventory(inventory)
+	require.Equal(t, inventory, copied)
+
+	copied["Nested"].(map[string]interface{})["Key"] = "changed"
+	copied["YAML"].(map[interface{}]interface{})["Key"] = "changed"
+	copied["List"].([]interface{})[0].(map[string]interface{})["Key"] = "changed"
+	copied["Str"] = "changed"
+	require.Equal(t, map[string]interface{}{
+		"Nested": map[string]interface{}{"Key": "value"},
+		"YAML":   map[interface{}]interface{}{"Key": "value"},
+		"List":   []interface{}{map[string]interface{}{"Key": "value"}},
+		"Str":    "str",
+	}, inventory)
+}
+
+func TestSetInventoryValue(t *testing.T) {
+	t.Log("Top level key")
+	{
+		inventory, err := SetInventoryValue(nil, "KeyOne", "one")
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"KeyOne": "one"}, inventory)
+	}
+
+	t.Log("Nested key - missing maps are created, the input is not modified")
+	{
+		orig := map[string]interface{}{
+			"Nested": map[string]interface{}{"KeyB": "b"},
+		}
+		inventory, err := SetInventoryValue(orig, "Nested.KeyA.Key1", "foo")
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{
+			"Nested": map[string]interface{}{
+				"KeyA": map[string]interface{}{"Key1": "foo"},
+				"KeyB": "b",
+			},
+		}, inventory)
+		require.Equal(t, map[string]interface{}{"KeyB": "b"}, orig["Nested"])
+	}
+
+	t.Log("Value on the path is not a map")
+	{
+		_, err := SetInventoryValue(map[string]interface{}{"Nested": "str"}, "Nested.KeyA", "foo")
+		require.EqualError(t, err, "Failed to set Nested.KeyA: Nested is not a map (string)")
+	}
+
+	t.Log("List index - the input is not modified")
+	{
+		orig := map[string]interface{}{
+			"Services": []interface{}{
+				map[string]interface{}{"Name": "a"},
+				"b",
+			},
+		}
+		inventory, err := SetInventoryValue(orig, "Services[0].Name", "x")
+		require.NoError(t, err)
+		inventory, err = SetInventoryValue(inventory, "Services[1]", "y")
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{
+			"Services": []interface{}{
+				map[string]interface{}{"Name": "x"},
+				"y",
+			},
+		}, inventory)
+		require.Equal(t, []interface{}{map[string]interface{}{"Name": "a"}, "b"}, orig["Services"])
+	}
+
+	t.Log("List index - not a list or out of range")
+	{
+		inventory := map[string]interface{}{"Services": []interface{}{"a"}, "Nested": map[string]interface{}{}}
+
+		_, err := SetInventoryValue(inventory, "Services[1]", "x")
+		require.EqualError(t, err, "Failed to set Services[1]: index out of range: Services[1] (length: 1)")
+
+		_, err = SetInventoryValue(inventory, "Nested[0]", "x")
+		require.EqualError(t, err, "Failed to set Nested[0]: Nested is not a list (map[string]interface {})")
+
+		_, err = SetInventoryValue(inventory, "Missing[0].Name", "x")
+		require.EqualError(t, err, "Failed to set Missing[0].Name: Missing is not a list (<nil>)")
+	}
+
+	t.Log("Invalid key path")
+	{
+		_, err := SetInventoryValue(nil, "Nested..KeyA", "foo")
+		require.EqualError(t, err, "Invalid key path: Nested..KeyA - empty key")
+
+		_, err = SetInventoryValue(nil, "Services[x]", "foo")
+		require.EqualError(t, err, "Invalid key path: Services[x] - invalid index: [x]")
+	}
+}
+
+func TestParseInventoryValue(t *testing.T) {
+	require.Equal(t, true, ParseInventoryValue("true"))
+	require.Equal(t, false, ParseInventoryValue("false"))
+	require.Equal(t, 42, ParseInventoryValue("42"))
+	require.Equal(t, -3, ParseInventoryValue("-3"))
+	require.Equal(t, 1.5, ParseInventoryValue("1.5"))
+	require.Equal(t, nil, ParseInventoryValue("null"))
+	require.Equal(t, "quoted 42", ParseInventoryValue(`"quoted 42"`))
+	require.Equal(t, []interface{}{"a", float64(1)}, ParseInventoryValue(`["a", 1]`))
+	require.Equal(t, map[string]interface{}{"a": map[string]interface{}{"b": true}}, ParseInventoryValue(`{"a": {"b": true}}`))
+
+	require.Equal(t, "foo", ParseInventoryValue("foo"))
+	require.Equal(t, "", ParseInventoryValue(""))
+	require.Equal(t, "1.2.3", ParseInventoryValue("1.2.3"))
+	require.Equal(t, "0x10", ParseInventoryValue("0x10"))
+	require.Equal(t, "true false", ParseInventoryValue("true false"))
+	require.Equal(t, "{invalid", ParseInventoryValue("{invalid"))
+}
+
+func TestInventoryValue(t *testing.T) {
+	inventory := map[string]interface{}{
+		"KeyOne": "one",
+		"Nested": map[string]interface{}{
+			"KeyA": map[string]interface{}{"Key1": "A1"},
+		},
+	}
+
+	val, err := InventoryValue(inventory, "KeyOne")
+	require.NoError(t, err)
+	require.Equal(t, "one", val)
+
+	val, err = InventoryValue(inventory, "Nested.KeyA.Key1")
+	require.NoError(t, err)
+	require.Equal(t, "A1", val)
+
+	val, err = InventoryValue(inventory, "Nested.KeyA")
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"Key1": "A1"}, val)
+
+	_, err = InventoryValue(inventory, "Nested.KeyB.Key1")
+	require.EqualError(t, err, "No value found for key: Nested.KeyB.Key1 - missing key: Nested.KeyB")
+
+	_, err = InventoryValue(inventory, "Missing")
+	require.EqualError(t, err, "No value found for key: Missing")
+
+	_, err = InventoryValue(inventory, "KeyOne.Sub")
+	require.EqualError(t, err, "No value found for key: KeyOne.Sub - KeyOne is not a map (string)")
+}
+
+func TestValueAtPath(t *testing.T) {
+	value := map[string]interface{}{
+		"Services": []interface{}{
+			map[string]interface{}{"Name": "api", "Ports": []int{80, 443}},
+			map[interface{}]interface{}{"Name": "web"},
+		},
+	}
+
+	val, err := ValueAtPath(value, "Services[0].Ports[1]")
+	require.NoError(t, err)
+	require.Equal(t, 443, val)
+
+	val, err = ValueAtPath(value, "Services[1].Name")
+	require.NoError(t, err)
+	require.Equal(t, "web", val)
+
+	val, err = ValueAtPath([]interface{}{[]interface{}{"a", "b"}}, "[0][1]")
+	require.NoError(t, err)
+	require.Equal(t, "b", val)
+
+	_, err = ValueAtPath(value, "Services[2].Name")
+	require.EqualError(t, err, "No value found for key: Services[2].Name - index out of range: Services[2] (length: 2)")
+
+	_, err = ValueAtPath(value, "Services[0].Name[0]")
+	require.EqualError(t, err, "No value found for key: Services[0].Name[0] - Services[0].Name is not a list (string)")
+
+	_, err = ValueAtPath(value, "Services.Name")
+	require.EqualError(t, err, "No value found for key: Services.Name - Services is not a map ([]interface {})")
+
+	_, err = ValueAtPath("str", "Key")
+	require.EqualError(t, err, "No value found for key: Key - the root is not a map (string)")
+
+	for _, invalidPath := range []string{"", ".Key", "Key.", "Key..Sub", "Key.[0]", "Key[0", "Key[-1]", "Key[a]", "Key[0]Sub"} {
+		_, err := ValueAtPath(value, invalidPath)
+		require.Error(t, err, invalidPath)
+		require.Contains(t, err.Error(), "Invalid key path", invalidPath)
+
+		_, err = HasValueAtPath(value, invalidPath)
+		require.Error(t, err, invalidPath)
+	}
+
+	isFound, err := HasValueAtPath(value, "Services[1].Name")
+	require.NoError(t, err)
+	require.True(t, isFound)
+
+	isFound, err = HasValueAtPath(value, "Services[1].Ports")
+	require.NoError(t, err)
+	require.False(t, isFound)
+}
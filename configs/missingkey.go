@@ -0,0 +1,26 @@
+package configs
+
+import "github.com/pkg/errors"
+
+// MissingKeyMode defines what happens if a template uses a map key which is not in the inventory,
+// see the missingkey option of text/template
+type MissingKeyMode string
+
+const (
+	// MissingKeyError fails the rendering, this is the default
+	MissingKeyError MissingKeyMode = "error"
+	// MissingKeyZero returns the zero value of the map's value type, nil for the inventory's maps
+	MissingKeyZero MissingKeyMode = "zero"
+	// MissingKeyDefault returns no value, which is printed as <no value>
+	MissingKeyDefault MissingKeyMode = "default"
+)
+
+// Validate returns an error if the mode is not one of the supported modes.
+// An empty mode is valid, it means the default (MissingKeyError).
+func (mode MissingKeyMode) Validate() error {
+	switch mode {
+	case "", MissingKeyError, MissingKeyZero, MissingKeyDefault:
+		return nil
+	}
+	return errors.Errorf("Invalid missing key mode: %s, has to be one of: %s, %s, %s", mode, MissingKeyError, MissingKeyZero, MissingKeyDefault)
+}
@@ -0,0 +1,14 @@
+package configs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMissingKeyMode_Validate(t *testing.T) {
+	for _, mode := range []MissingKeyMode{"", MissingKeyError, MissingKeyZero, MissingKeyDefault} {
+		require.NoError(t, mode.Validate(), mode)
+	}
+	require.EqualError(t, MissingKeyMode("invalid").Validate(), "Invalid missing key mode: invalid, has to be one of: error, zero, default")
+}
@@ -0,0 +1,26 @@
+package configs
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// PluginModel is a template function implemented by an external executable
+type PluginModel struct {
+	// Command is the executable. A relative path which contains a slash (e.g. ./scripts/semver.sh)
+	// is relative to the config file's directory, a name without a slash is looked up in PATH.
+	Command string `json:"command" yaml:"command" toml:"command"`
+	// Args are passed to the executable as command line arguments, before every call.
+	Args []string `json:"args,omitempty" yaml:"args,omitempty" toml:"args,omitempty"`
+}
+
+// ResolvePluginCommands makes the relative plugin command paths relative to the config file's directory (configDir).
+func (model *Model) ResolvePluginCommands(configDir string) {
+	for name, aPlugin := range model.Plugins {
+		if filepath.IsAbs(aPlugin.Command) || !strings.ContainsRune(filepath.ToSlash(aPlugin.Command), '/') {
+			continue
+		}
+		aPlugin.Command = filepath.Join(configDir, aPlugin.Command)
+		model.Plugins[name] = aPlugin
+	}
+}
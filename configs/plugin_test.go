@@ -0,0 +1,24 @@
+package configs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestModel_ResolvePluginCommands(t *testing.T) {
+	model := Model{Plugins: map[string]PluginModel{
+		"relative": {Command: "./scripts/semver.sh", Args: []string{"--strict"}},
+		"nested":   {Command: "scripts/semver.sh"},
+		"absolute": {Command: "/usr/local/bin/semver"},
+		"inPath":   {Command: "semver"},
+	}}
+	model.ResolvePluginCommands("path/to/config")
+
+	require.Equal(t, map[string]PluginModel{
+		"relative": {Command: "path/to/config/scripts/semver.sh", Args: []string{"--strict"}},
+		"nested":   {Command: "path/to/config/scripts/semver.sh"},
+		"absolute": {Command: "/usr/local/bin/semver"},
+		"inPath":   {Command: "semver"},
+	}, model.Plugins)
+}
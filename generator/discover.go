@@ -0,0 +1,166 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// TemplateFileExtension is the extension of the template files
+const TemplateFileExtension = ".gg"
+
+// findTemplateFiles collects the .gg template files in rootDir and returns a map of
+// template path => output path. See outputPathForTemplate for how the output path is determined.
+//
+// If recursive is false only the files directly in rootDir are considered,
+// otherwise the whole directory tree is walked.
+// A file is only included if it matches at least one of the include globs (or if no include glob is specified),
+// and files and directories matching any of the exclude globs are skipped.
+// See matchesGlob for the glob syntax.
+// The skipDirs directories (e.g. the partials directory) are always skipped, empty items are ignored.
+func findTemplateFiles(fs FileSystem, rootDir, outputRootDir string, recursive bool, includes, excludes, skipDirs []string) (map[string]string, error) {
+	skipDirMap := map[string]bool{}
+	for _, aDir := range skipDirs {
+		if aDir != "" {
+			skipDirMap[filepath.Clean(aDir)] = true
+		}
+	}
+
+	templateFiles := map[string]string{}
+
+	walkFn := func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPth, err := filepath.Rel(rootDir, pth)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if relPth == "." {
+			return nil
+		}
+
+		if info.IsDir() {
+			if !recursive || matchesAnyGlob(excludes, relPth, true) || skipDirMap[filepath.Clean(pth)] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !strings.HasSuffix(pth, TemplateFileExtension) {
+			return nil
+		}
+		if matchesAnyGlob(excludes, relPth, false) {
+			return nil
+		}
+		if len(includes) > 0 && !matchesAnyGlob(includes, relPth, false) {
+			return nil
+		}
+
+		templateFiles[pth] = outputPathForTemplate(pth, rootDir, outputRootDir)
+		return nil
+	}
+
+	if err := fs.Walk(rootDir, walkFn); err != nil {
+		return nil, errors.Wrapf(err, "Failed to scan .gg template files (in: %s)", rootDir)
+	}
+	return templateFiles, nil
+}
+
+// sortedTemplatePaths returns the template paths of the map returned by findTemplateFiles, in alphabetical order
+func sortedTemplatePaths(templateFiles map[string]string) []string {
+	templatePths := make([]string, 0, len(templateFiles))
+	for aTemplatePth := range templateFiles {
+		templatePths = append(templatePths, aTemplatePth)
+	}
+	sort.Strings(templatePths)
+	return templatePths
+}
+
+// removeLayoutTemplates removes the layouts referenced in the front matter of the templates from templateFiles
+// (the map returned by findTemplateFiles), so that the layouts kept next to the templates are not generated on their own.
+// A layout which can't be found is not an error here, it's reported when the template is rendered.
+func removeLayoutTemplates(fs FileSystem, templateFiles map[string]string, layoutsDir string) error {
+	layoutPths := map[string]bool{}
+	for aTemplatePth := range templateFiles {
+		templateCont, err := fs.ReadFile(aTemplatePth)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to read template content (path: %s)", aTemplatePth)
+		}
+		frontMatter, _, err := splitFrontMatter(string(templateCont))
+		if err != nil {
+			return errors.Wrapf(err, "Failed to parse the front matter of template: %s", aTemplatePth)
+		}
+		if len(frontMatter.Layout) < 1 {
+			continue
+		}
+		if pth, err := layoutPath(fs, frontMatter.Layout, layoutsDir, aTemplatePth); err == nil {
+			layoutPths[filepath.Clean(pth)] = true
+		}
+	}
+
+	for aTemplatePth := range templateFiles {
+		if layoutPths[filepath.Clean(aTemplatePth)] {
+			delete(templateFiles, aTemplatePth)
+		}
+	}
+	return nil
+}
+
+// outputPathForTemplate returns the path where the file generated from templatePth should be written.
+//
+// The output path has the same path relative to outputRootDir as the template has relative to rootDir,
+// without the .gg extension. E.g. templates/a/b.yml.gg => build/a/b.yml for rootDir "templates" and outputRootDir "build".
+// If outputRootDir is empty, or if the template is not inside rootDir, the output will be next to the template.
+func outputPathForTemplate(templatePth, rootDir, outputRootDir string) string {
+	outputPth := strings.TrimSuffix(templatePth, TemplateFileExtension)
+	if outputRootDir == "" {
+		return outputPth
+	}
+
+	relPth, err := filepath.Rel(rootDir, outputPth)
+	if err != nil || relPth == ".." || strings.HasPrefix(relPth, ".."+string(filepath.Separator)) {
+		return outputPth
+	}
+	return filepath.Join(outputRootDir, relPth)
+}
+
+func matchesAnyGlob(globs []string, relPth string, isDir bool) bool {
+	for _, aGlob := range globs {
+		if matchesGlob(aGlob, relPth, isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesGlob reports whether the slash separated relPth matches the glob pattern.
+//
+//   - A pattern which does not contain a slash (e.g. "vendor" or "*.md.gg") is matched against the name
+//     of the file or directory, regardless of where it is in the tree.
+//   - A pattern which contains a slash (e.g. "docs/*.gg") is matched against the whole path,
+//     relative to the scanned root directory.
+//   - A pattern ending with a slash (e.g. "build/") only matches directories.
+//
+// The pattern syntax is the one supported by filepath.Match.
+func matchesGlob(pattern, relPth string, isDir bool) bool {
+	if strings.HasSuffix(pattern, "/") {
+		if !isDir {
+			return false
+		}
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+
+	relPth = filepath.ToSlash(relPth)
+	subject := relPth
+	if !strings.Contains(pattern, "/") {
+		subject = relPth[strings.LastIndex(relPth, "/")+1:]
+	}
+
+	isMatch, err := filepath.Match(pattern, subject)
+	return err == nil && isMatch
+}
@@ -0,0 +1,143 @@
+package generator
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestTree(t *testing.T, relPths ...string) string {
+	tmpDir, err := ioutil.TempDir("", "gotgen-test")
+	require.NoError(t, err)
+
+	for _, aRelPth := range relPths {
+		pth := filepath.Join(tmpDir, aRelPth)
+		require.NoError(t, os.MkdirAll(filepath.Dir(pth), 0755))
+		require.NoError(t, fileutil.WriteStringToFile(pth, aRelPth))
+	}
+	return tmpDir
+}
+
+func Test_findTemplateFiles(t *testing.T) {
+	tmpDir := createTestTree(t,
+		"a.txt.gg",
+		"not-a-template.txt",
+		"sub/b.yml.gg",
+		"sub/deeper/c.md.gg",
+		"vendor/lib/d.gg",
+		"docs/e.md.gg",
+	)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	t.Log("Not recursive - only the root directory")
+	{
+		files, err := findTemplateFiles(OSFileSystem{}, tmpDir, "", false, nil, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			filepath.Join(tmpDir, "a.txt.gg"): filepath.Join(tmpDir, "a.txt"),
+		}, files)
+	}
+
+	t.Log("Recursive - outputs are next to their templates")
+	{
+		files, err := findTemplateFiles(OSFileSystem{}, tmpDir, "", true, nil, []string{"vendor"}, nil)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			filepath.Join(tmpDir, "a.txt.gg"):           filepath.Join(tmpDir, "a.txt"),
+			filepath.Join(tmpDir, "sub/b.yml.gg"):       filepath.Join(tmpDir, "sub/b.yml"),
+			filepath.Join(tmpDir, "sub/deeper/c.md.gg"): filepath.Join(tmpDir, "sub/deeper/c.md"),
+			filepath.Join(tmpDir, "docs/e.md.gg"):       filepath.Join(tmpDir, "docs/e.md"),
+		}, files)
+	}
+
+	t.Log("Recursive - include and exclude globs")
+	{
+		files, err := findTemplateFiles(OSFileSystem{}, tmpDir, "", true, []string{"*.md.gg"}, []string{"vendor", "docs/"}, nil)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			filepath.Join(tmpDir, "sub/deeper/c.md.gg"): filepath.Join(tmpDir, "sub/deeper/c.md"),
+		}, files)
+	}
+
+	t.Log("Recursive - skipped directories")
+	{
+		files, err := findTemplateFiles(OSFileSystem{}, tmpDir, "", true, nil, []string{"vendor"}, []string{"", filepath.Join(tmpDir, "sub")})
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			filepath.Join(tmpDir, "a.txt.gg"):     filepath.Join(tmpDir, "a.txt"),
+			filepath.Join(tmpDir, "docs/e.md.gg"): filepath.Join(tmpDir, "docs/e.md"),
+		}, files)
+	}
+
+	t.Log("Recursive - exclude with a path glob")
+	{
+		files, err := findTemplateFiles(OSFileSystem{}, tmpDir, "", true, nil, []string{"vendor", "sub/*"}, nil)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			filepath.Join(tmpDir, "a.txt.gg"):     filepath.Join(tmpDir, "a.txt"),
+			filepath.Join(tmpDir, "docs/e.md.gg"): filepath.Join(tmpDir, "docs/e.md"),
+		}, files)
+	}
+}
+
+func Test_removeLayoutTemplates(t *testing.T) {
+	tmpDir := createTestTree(t, "base.gg", "base", "other.gg", "sub/page.gg")
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "a.txt.gg"), "---gotgen\nlayout: base\n---\n"))
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "sub/b.txt.gg"), "---gotgen\nlayout: page.gg\n---\n"))
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "c.txt.gg"), "---gotgen\nlayout: missing\n---\n"))
+
+	files, err := findTemplateFiles(OSFileSystem{}, tmpDir, "", true, nil, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, removeLayoutTemplates(OSFileSystem{}, files, ""))
+	require.Equal(t, map[string]string{
+		filepath.Join(tmpDir, "a.txt.gg"):     filepath.Join(tmpDir, "a.txt"),
+		filepath.Join(tmpDir, "c.txt.gg"):     filepath.Join(tmpDir, "c.txt"),
+		filepath.Join(tmpDir, "other.gg"):     filepath.Join(tmpDir, "other"),
+		filepath.Join(tmpDir, "sub/b.txt.gg"): filepath.Join(tmpDir, "sub/b.txt"),
+	}, files)
+}
+
+func Test_matchesGlob(t *testing.T) {
+	require.True(t, matchesGlob("vendor", "vendor", true))
+	require.True(t, matchesGlob("vendor", "a/b/vendor", true))
+	require.True(t, matchesGlob("vendor", "vendor", false))
+	require.False(t, matchesGlob("vendor/", "vendor", false))
+	require.True(t, matchesGlob("vendor/", "vendor", true))
+
+	require.True(t, matchesGlob("*.md.gg", "docs/readme.md.gg", false))
+	require.False(t, matchesGlob("*.md.gg", "docs/readme.yml.gg", false))
+
+	require.True(t, matchesGlob("docs/*.gg", "docs/readme.md.gg", false))
+	require.False(t, matchesGlob("docs/*.gg", "sub/docs/readme.md.gg", false))
+
+	require.False(t, matchesGlob("[", "a", false), "Invalid pattern should never match")
+}
+
+func Test_outputPathForTemplate(t *testing.T) {
+	t.Log("No output root - next to the template")
+	{
+		require.Equal(t, "a/b.yml", outputPathForTemplate("a/b.yml.gg", ".", ""))
+		require.Equal(t, "templates/a/b.yml", outputPathForTemplate("templates/a/b.yml.gg", "templates", ""))
+	}
+
+	t.Log("Output root - same relative layout")
+	{
+		require.Equal(t, "build/a/b.yml", outputPathForTemplate("templates/a/b.yml.gg", "templates", "build"))
+		require.Equal(t, "build/b.yml", outputPathForTemplate("templates/b.yml.gg", "templates/", "build"))
+		require.Equal(t, "build/a/b.yml", outputPathForTemplate("a/b.yml.gg", ".", "build"))
+	}
+
+	t.Log("Template outside of the source root - next to the template")
+	{
+		require.Equal(t, "other/b.yml", outputPathForTemplate("other/b.yml.gg", "templates", "build"))
+	}
+}
@@ -0,0 +1,110 @@
+package generator
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/bitrise-io/gotgen/configs"
+	"github.com/pkg/errors"
+)
+
+// templateEngine is the Go template package a template is rendered with
+type templateEngine string
+
+const (
+	// engineText renders with text/template, which doesn't escape anything. This is the default.
+	engineText templateEngine = "text"
+	// engineHTML renders with html/template, which escapes the values based on their context in the HTML (contextual auto-escaping)
+	engineHTML templateEngine = "html"
+)
+
+// HTMLTemplateFileExtension is the extension of the templates rendered with html/template by default, e.g. status.html.gg
+const HTMLTemplateFileExtension = ".html" + TemplateFileExtension
+
+// engineForTemplate returns the engine specified in the template's front matter,
+// or if it's not specified the default engine for the template's extension (see HTMLTemplateFileExtension).
+func engineForTemplate(templatePath, frontMatterEngine string) (templateEngine, error) {
+	switch templateEngine(frontMatterEngine) {
+	case engineText, engineHTML:
+		return templateEngine(frontMatterEngine), nil
+	case "":
+		if strings.HasSuffix(templatePath, HTMLTemplateFileExtension) {
+			return engineHTML, nil
+		}
+		return engineText, nil
+	}
+	return "", errors.Errorf("Invalid engine (%s) in front matter, has to be one of: %s, %s", frontMatterEngine, engineText, engineHTML)
+}
+
+// htmlFunctions returns the template functions which mark trusted content, which is not escaped by the html engine.
+// With the text engine they return the content as-is.
+func htmlFunctions() template.FuncMap {
+	return template.FuncMap{
+		"safeHTML": func(value interface{}) htmltemplate.HTML {
+			return htmltemplate.HTML(toString(value))
+		},
+		"safeJS": func(value interface{}) htmltemplate.JS {
+			return htmltemplate.JS(toString(value))
+		},
+	}
+}
+
+// engineTemplate is the part of the Template API of text/template and html/template which is used to render the templates
+type engineTemplate interface {
+	New(name string) engineTemplate
+	Delims(left, right string) engineTemplate
+	Parse(text string) (engineTemplate, error)
+	Execute(w io.Writer, data interface{}) error
+	ExecuteTemplate(w io.Writer, name string, data interface{}) error
+}
+
+// newEngineTemplate creates an empty template of the engine, with the functions and the missing key mode
+func newEngineTemplate(engine templateEngine, name string, funcs template.FuncMap, missingKey configs.MissingKeyMode) engineTemplate {
+	missingKeyOption := "missingkey=" + string(missingKey)
+	if engine == engineHTML {
+		return htmlEngineTemplate{htmltemplate.New(name).Funcs(htmltemplate.FuncMap(funcs)).Option(missingKeyOption)}
+	}
+	return textEngineTemplate{template.New(name).Funcs(funcs).Option(missingKeyOption)}
+}
+
+type textEngineTemplate struct {
+	*template.Template
+}
+
+func (tmpl textEngineTemplate) New(name string) engineTemplate {
+	return textEngineTemplate{tmpl.Template.New(name)}
+}
+
+func (tmpl textEngineTemplate) Delims(left, right string) engineTemplate {
+	return textEngineTemplate{tmpl.Template.Delims(left, right)}
+}
+
+func (tmpl textEngineTemplate) Parse(text string) (engineTemplate, error) {
+	parsed, err := tmpl.Template.Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return textEngineTemplate{parsed}, nil
+}
+
+type htmlEngineTemplate struct {
+	*htmltemplate.Template
+}
+
+func (tmpl htmlEngineTemplate) New(name string) engineTemplate {
+	return htmlEngineTemplate{tmpl.Template.New(name)}
+}
+
+func (tmpl htmlEngineTemplate) Delims(left, right string) engineTemplate {
+	return htmlEngineTemplate{tmpl.Template.Delims(left, right)}
+}
+
+func (tmpl htmlEngineTemplate) Parse(text string) (engineTemplate, error) {
+	parsed, err := tmpl.Template.Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return htmlEngineTemplate{parsed}, nil
+}
@@ -0,0 +1,100 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_engineForTemplate(t *testing.T) {
+	for _, tc := range []struct {
+		templatePath      string
+		frontMatterEngine string
+		expected          templateEngine
+	}{
+		{"status.html.gg", "", engineHTML},
+		{"status.html.gg", "text", engineText},
+		{"config.yml.gg", "", engineText},
+		{"config.yml.gg", "html", engineHTML},
+		{"page.htm.gg", "", engineText},
+	} {
+		engine, err := engineForTemplate(tc.templatePath, tc.frontMatterEngine)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, engine, tc.templatePath+" "+tc.frontMatterEngine)
+	}
+
+	_, err := engineForTemplate("status.html.gg", "jinja")
+	require.EqualError(t, err, "Invalid engine (jinja) in front matter, has to be one of: text, html")
+}
+
+func TestGenerator_RenderTemplate_htmlEngine(t *testing.T) {
+	tmpDir := createTestTree(t)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	partialsDir := filepath.Join(tmpDir, "partials")
+	require.NoError(t, os.MkdirAll(partialsDir, 0755))
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(partialsDir, "status.html.gg"), `{{ define "status" }}<b>{{ .State }}</b>{{ end }}`))
+
+	gen := newTestGenerator(t, Options{
+		Inventory: map[string]interface{}{
+			"Title":   "Tom & Jerry <script>",
+			"Message": "<i>trusted</i>",
+			"Script":  "var x = 1;",
+			"Link":    "javascript:alert(1)",
+			"Service": map[string]interface{}{"State": "<up>"},
+		},
+		PartialsDir: partialsDir,
+	})
+
+	t.Log("html engine by the .html.gg extension - contextual escaping")
+	{
+		genCont, err := gen.RenderTemplate("status.html.gg", `<h1>{{ .Title }}</h1><a href="{{ .Link }}">x</a><script>var t = {{ .Title }};</script>`)
+		require.NoError(t, err)
+		require.Equal(t, `<h1>Tom &amp; Jerry &lt;script&gt;</h1><a href="#ZgotmplZ">x</a><script>var t = "Tom \u0026 Jerry \u003cscript\u003e";</script>`, genCont)
+	}
+
+	t.Log("safeHTML and safeJS")
+	{
+		genCont, err := gen.RenderTemplate("status.html.gg", `<p>{{ .Message | safeHTML }}</p><script>{{ safeJS .Script }}</script>`)
+		require.NoError(t, err)
+		require.Equal(t, `<p><i>trusted</i></p><script>var x = 1;</script>`, genCont)
+	}
+
+	t.Log("Partials - with template and include, without double escaping")
+	{
+		genCont, err := gen.RenderTemplate("status.html.gg", `{{ template "status" .Service }} {{ include "status" .Service }}`)
+		require.NoError(t, err)
+		require.Equal(t, `<b>&lt;up&gt;</b> <b>&lt;up&gt;</b>`, genCont)
+	}
+
+	t.Log("Engine in the front matter")
+	{
+		genCont, err := gen.RenderTemplate("status.html.gg", "---gotgen\nengine: text\n---\n<h1>{{ .Title }}</h1>")
+		require.NoError(t, err)
+		require.Equal(t, `<h1>Tom & Jerry <script></h1>`, genCont)
+
+		genCont, err = gen.RenderTemplate("status.txt.gg", "---gotgen\nengine: html\n---\n<h1>{{ .Title }}</h1>")
+		require.NoError(t, err)
+		require.Equal(t, `<h1>Tom &amp; Jerry &lt;script&gt;</h1>`, genCont)
+
+		_, err = gen.RenderTemplate("status.txt.gg", "---gotgen\nengine: jinja\n---\n")
+		require.Error(t, err)
+	}
+
+	t.Log("text engine - safeHTML returns the content as-is")
+	{
+		genCont, err := gen.RenderTemplate("status.txt.gg", `{{ .Message | safeHTML }}`)
+		require.NoError(t, err)
+		require.Equal(t, `<i>trusted</i>`, genCont)
+	}
+
+	t.Log("Errors are reported with the template file and line")
+	{
+		_, err := gen.RenderTemplate("status.html.gg", "---gotgen\nengine: html\n---\n<p>\n{{ fail \"broken\" }}</p>")
+		require.EqualError(t, err, `template: status.html.gg:5:3: executing "status.html.gg" at <fail "broken">: error calling fail: broken`)
+	}
+}
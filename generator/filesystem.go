@@ -0,0 +1,34 @@
+package generator
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// FileSystem is used by the Generator to read the templates, partials and layouts.
+//
+// Stat has to return an error for which os.IsNotExist is true if the file does not exist.
+type FileSystem interface {
+	ReadFile(pth string) ([]byte, error)
+	Stat(pth string) (os.FileInfo, error)
+	Walk(root string, walkFn filepath.WalkFunc) error
+}
+
+// OSFileSystem is the FileSystem of the operating system
+type OSFileSystem struct{}
+
+// ReadFile ...
+func (OSFileSystem) ReadFile(pth string) ([]byte, error) {
+	return ioutil.ReadFile(pth)
+}
+
+// Stat ...
+func (OSFileSystem) Stat(pth string) (os.FileInfo, error) {
+	return os.Stat(pth)
+}
+
+// Walk ...
+func (OSFileSystem) Walk(root string, walkFn filepath.WalkFunc) error {
+	return filepath.Walk(root, walkFn)
+}
@@ -0,0 +1,137 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/gotgen/configs"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// frontMatterOpening opens the front matter, it has to be the first line of the template.
+	// A plain --- line is not enough, that's the YAML document separator, which can be the first line of a YAML template.
+	frontMatterOpening = "---gotgen"
+	// frontMatterClosing closes the front matter
+	frontMatterClosing = "---"
+)
+
+// frontMatterModel is the optional YAML header of a template, between a ---gotgen and a --- line at the top of the file:
+//
+//	---gotgen
+//	layout: base
+//	output: config.yml
+//	mode: "0644"
+//	delimiter:
+//	  left: "[["
+//	  right: "]]"
+//	missing_key: zero
+//	engine: html
+//	inventory:
+//	  LocalKey: local value
+//	---
+//
+// or for a matrix template, which is rendered once for every item of an inventory list or map:
+//
+//	---gotgen
+//	each: Services
+//	as: Service
+//	output: services/{{ .Service.Name }}.yml
+//	---
+type frontMatterModel struct {
+	// Layout is the name of the layout template the template is rendered into, see readLayout
+	Layout string `yaml:"layout"`
+	// Output overrides the path of the generated file.
+	// A relative path is relative to the directory where the file would be generated by default.
+	// For a matrix template it's a template, rendered for every item.
+	Output string `yaml:"output"`
+	// Mode is the permission of the generated file, in octal format, e.g. "0755"
+	Mode string `yaml:"mode"`
+	// Delimiter overrides the config's delimiters for this template.
+	// Partials and layouts are always parsed with the config's delimiters.
+	Delimiter configs.DelimiterModel `yaml:"delimiter"`
+	// MissingKey overrides the config's missing key mode for this template
+	MissingKey configs.MissingKeyMode `yaml:"missing_key"`
+	// Engine is the template package the template is rendered with: text (text/template) or html (html/template).
+	// Defaults to html for .html.gg templates, and to text for every other template.
+	Engine string `yaml:"engine"`
+	// Inventory is merged into the inventory (see configs.MergeInventory), only for this template
+	Inventory map[string]interface{} `yaml:"inventory"`
+	// Each is the dot separated key path of an inventory list or map. If specified the template is a matrix template:
+	// it's rendered once for every item, and it generates one file for every item.
+	Each string `yaml:"each"`
+	// As is the inventory key of the current item in a matrix template, defaults to Item.
+	// The list index or map key of the item is available as As + "Key" (e.g. ItemKey).
+	As string `yaml:"as"`
+}
+
+// matrixItemName returns the inventory key of the current item in a matrix template
+func (frontMatter frontMatterModel) matrixItemName() string {
+	if frontMatter.As == "" {
+		return defaultMatrixItemName
+	}
+	return frontMatter.As
+}
+
+// fileMode parses the Mode of the front matter. Returns 0 if no Mode is specified.
+func (frontMatter frontMatterModel) fileMode() (os.FileMode, error) {
+	if frontMatter.Mode == "" {
+		return 0, nil
+	}
+	mode, err := strconv.ParseUint(frontMatter.Mode, 8, 32)
+	if err != nil {
+		return 0, errors.Errorf("Invalid mode (%s) in front matter, has to be an octal number, e.g. \"0644\"", frontMatter.Mode)
+	}
+	return os.FileMode(mode), nil
+}
+
+// outputPath returns the path of the generated file, based on the Output of the front matter
+// and on the defaultOutputPath where the file would be generated without a front matter.
+func (frontMatter frontMatterModel) outputPath(defaultOutputPath string) string {
+	if frontMatter.Output == "" {
+		return defaultOutputPath
+	}
+	if filepath.IsAbs(frontMatter.Output) {
+		return frontMatter.Output
+	}
+	return filepath.Join(filepath.Dir(defaultOutputPath), frontMatter.Output)
+}
+
+// splitFrontMatter splits the optional front matter from the content of the template.
+// Returns the parsed front matter and the content without the front matter.
+// If the content does not start with a ---gotgen line it returns an empty front matter and the unchanged content,
+// e.g. a YAML template starting with a --- document separator.
+func splitFrontMatter(content string) (frontMatterModel, string, error) {
+	firstLine, rest := splitFirstLine(content)
+	if strings.TrimRight(firstLine, "\r\n") != frontMatterOpening {
+		return frontMatterModel{}, content, nil
+	}
+
+	frontMatterLines := []string{}
+	for rest != "" {
+		var line string
+		line, rest = splitFirstLine(rest)
+		if strings.TrimRight(line, "\r\n") == frontMatterClosing {
+			frontMatter := frontMatterModel{}
+			if err := yaml.UnmarshalStrict([]byte(strings.Join(frontMatterLines, "")), &frontMatter); err != nil {
+				return frontMatterModel{}, "", errors.Wrap(err, "Failed to parse front matter")
+			}
+			frontMatter.Inventory = configs.NormalizeMap(frontMatter.Inventory)
+			return frontMatter, rest, nil
+		}
+		frontMatterLines = append(frontMatterLines, line)
+	}
+
+	return frontMatterModel{}, "", errors.Errorf("Front matter is not closed, no closing %s line found", frontMatterClosing)
+}
+
+// splitFirstLine returns the first line (including its line ending) and the rest of the content.
+func splitFirstLine(content string) (string, string) {
+	if idx := strings.Index(content, "\n"); idx >= 0 {
+		return content[:idx+1], content[idx+1:]
+	}
+	return content, ""
+}
@@ -0,0 +1,123 @@
+package generator
+
+import (
+	"os"
+	"testing"
+
+	"github.com/bitrise-io/gotgen/configs"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_splitFrontMatter(t *testing.T) {
+	t.Log("No front matter")
+	{
+		frontMatter, content, err := splitFrontMatter("Content\n---\n")
+		require.NoError(t, err)
+		require.Equal(t, frontMatterModel{}, frontMatter)
+		require.Equal(t, "Content\n---\n", content)
+	}
+
+	t.Log("No front matter - YAML document separator on the first line")
+	{
+		frontMatter, content, err := splitFrontMatter("---\napiVersion: v1\n---\nkind: Service\n")
+		require.NoError(t, err)
+		require.Equal(t, frontMatterModel{}, frontMatter)
+		require.Equal(t, "---\napiVersion: v1\n---\nkind: Service\n", content)
+	}
+
+	t.Log("Empty content")
+	{
+		frontMatter, content, err := splitFrontMatter("")
+		require.NoError(t, err)
+		require.Equal(t, frontMatterModel{}, frontMatter)
+		require.Equal(t, "", content)
+	}
+
+	t.Log("Front matter")
+	{
+		frontMatter, content, err := splitFrontMatter("---gotgen\nlayout: base\n---\nContent\n---\n")
+		require.NoError(t, err)
+		require.Equal(t, frontMatterModel{Layout: "base"}, frontMatter)
+		require.Equal(t, "Content\n---\n", content)
+	}
+
+	t.Log("Front matter - CRLF line endings, empty content")
+	{
+		frontMatter, content, err := splitFrontMatter("---gotgen\r\nlayout: base\r\n---\r\n")
+		require.NoError(t, err)
+		require.Equal(t, frontMatterModel{Layout: "base"}, frontMatter)
+		require.Equal(t, "", content)
+	}
+
+	t.Log("Empty front matter")
+	{
+		frontMatter, content, err := splitFrontMatter("---gotgen\n---\nContent")
+		require.NoError(t, err)
+		require.Equal(t, frontMatterModel{}, frontMatter)
+		require.Equal(t, "Content", content)
+	}
+
+	t.Log("Every field")
+	{
+		frontMatter, content, err := splitFrontMatter(`---gotgen
+layout: base
+output: ../other.yml
+mode: 0755
+delimiter:
+  left: "[["
+  right: "]]"
+inventory:
+  Nested:
+    Key: value
+---
+Content`)
+		require.NoError(t, err)
+		require.Equal(t, frontMatterModel{
+			Layout:    "base",
+			Output:    "../other.yml",
+			Mode:      "0755",
+			Delimiter: configs.DelimiterModel{Left: "[[", Right: "]]"},
+			Inventory: map[string]interface{}{
+				"Nested": map[string]interface{}{"Key": "value"},
+			},
+		}, frontMatter)
+		require.Equal(t, "Content", content)
+	}
+
+	t.Log("Not closed front matter")
+	{
+		_, _, err := splitFrontMatter("---gotgen\nlayout: base\nContent")
+		require.EqualError(t, err, "Front matter is not closed, no closing --- line found")
+	}
+
+	t.Log("Unknown front matter key")
+	{
+		_, _, err := splitFrontMatter("---gotgen\nlayotu: base\n---\nContent")
+		require.Error(t, err)
+	}
+}
+
+func Test_frontMatterModel_fileMode(t *testing.T) {
+	mode, err := frontMatterModel{}.fileMode()
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0), mode)
+
+	mode, err = frontMatterModel{Mode: "0755"}.fileMode()
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0755), mode)
+
+	mode, err = frontMatterModel{Mode: "644"}.fileMode()
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0644), mode)
+
+	_, err = frontMatterModel{Mode: "rwx"}.fileMode()
+	require.EqualError(t, err, `Invalid mode (rwx) in front matter, has to be an octal number, e.g. "0644"`)
+}
+
+func Test_frontMatterModel_outputPath(t *testing.T) {
+	require.Equal(t, "build/a/b.yml", frontMatterModel{}.outputPath("build/a/b.yml"))
+	require.Equal(t, "build/a/c.yml", frontMatterModel{Output: "c.yml"}.outputPath("build/a/b.yml"))
+	require.Equal(t, "build/c.yml", frontMatterModel{Output: "../c.yml"}.outputPath("build/a/b.yml"))
+	require.Equal(t, "/tmp/c.yml", frontMatterModel{Output: "/tmp/c.yml"}.outputPath("build/a/b.yml"))
+	require.Equal(t, "c.yml", frontMatterModel{Output: "c.yml"}.outputPath("b.yml"))
+}
@@ -0,0 +1,414 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/bitrise-io/gotgen/configs"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// textTemplateBuiltinFuncNames are the functions predefined by text/template
+var textTemplateBuiltinFuncNames = []string{
+	"and", "call", "html", "index", "slice", "js", "len", "not", "or", "print", "printf", "println", "urlquery",
+	"eq", "ge", "gt", "le", "lt", "ne",
+}
+
+// BuiltinFuncNames returns the names of the functions available in every template, in alphabetical order:
+// the ones predefined by text/template and the ones added by gotgen (some of which replace a predefined one, e.g. slice).
+// A custom function (Options.Funcs) can only have one of these names if it's an explicit override (Options.OverrideFuncs).
+func BuiltinFuncNames() []string {
+	nameSet := map[string]bool{"include": true}
+	for _, name := range textTemplateBuiltinFuncNames {
+		nameSet[name] = true
+	}
+	for name := range createAvailableTemplateFunctions(nil) {
+		nameSet[name] = true
+	}
+
+	names := make([]string, 0, len(nameSet))
+	for name := range nameSet {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isBuiltinFuncName(name string) bool {
+	for _, aName := range BuiltinFuncNames() {
+		if aName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// customFuncs merges the custom functions and the overrides of the built-in functions into a single map.
+// Returns an error if a custom function has the name of a built-in function (see BuiltinFuncNames),
+// or if any of the functions is invalid (see template.FuncMap).
+func customFuncs(funcs, overrideFuncs template.FuncMap) (template.FuncMap, error) {
+	merged := template.FuncMap{}
+	for name, fn := range funcs {
+		if isBuiltinFuncName(name) {
+			return nil, errors.Errorf("Template function (%s) collides with a built-in function, use OverrideFuncs to override it", name)
+		}
+		if _, isFound := overrideFuncs[name]; isFound {
+			return nil, errors.Errorf("Template function (%s) is specified both in Funcs and OverrideFuncs", name)
+		}
+		merged[name] = fn
+	}
+	for name, fn := range overrideFuncs {
+		merged[name] = fn
+	}
+
+	if err := validateFuncs(merged); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return merged, nil
+}
+
+// validateFuncs returns an error if any of the functions would be rejected by text/template,
+// e.g. because its name is not a valid identifier or it has an unsupported signature.
+func validateFuncs(funcs template.FuncMap) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("Invalid template function: %v", r)
+		}
+	}()
+	template.New("").Funcs(funcs)
+	return nil
+}
+
+func createAvailableTemplateFunctions(inventory map[string]interface{}) template.FuncMap {
+	funcs := template.FuncMap{
+		"var": func(keyPath string) (interface{}, error) {
+			return inventoryValue(inventory, keyPath)
+		},
+		"varOr": func(keyPath string, defaultValue interface{}) (interface{}, error) {
+			isFound, err := hasInventoryValue(inventory, keyPath)
+			if err != nil || !isFound {
+				return defaultValue, err
+			}
+			return inventoryValue(inventory, keyPath)
+		},
+		"hasVar": func(keyPath string) (bool, error) {
+			return hasInventoryValue(inventory, keyPath)
+		},
+		// lookup is the same as var, for any value (e.g. a map returned by fromJson), e.g. {{ $conf | lookup "Servers[0].Host" }}
+		"lookup": func(keyPath string, value interface{}) (interface{}, error) {
+			return configs.ValueAtPath(value, keyPath)
+		},
+		"getenv": func(key string) string {
+			return os.Getenv(key)
+		},
+		"getenvRequired": func(key string) (string, error) {
+			if val := os.Getenv(key); len(val) > 0 {
+				return val, nil
+			}
+			return "", errors.Errorf("No environment variable value found for key: %s", key)
+		},
+		"yaml":             yamlFn,
+		"indentWithSpaces": indentWithSpaces,
+		"add":              add,
+		"subtract":         subtract,
+		"multiply":         multiply,
+		"divide":           divide,
+		"modulo":           modulo,
+	}
+	for name, fn := range stringFunctions() {
+		funcs[name] = fn
+	}
+	for name, fn := range encodingFunctions() {
+		funcs[name] = fn
+	}
+	for name, fn := range collectionFunctions() {
+		funcs[name] = fn
+	}
+	for name, fn := range defaultFunctions() {
+		funcs[name] = fn
+	}
+	for name, fn := range htmlFunctions() {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// inventoryValue returns the value at the keyPath (e.g. Services[0].Name, see configs.ValueAtPath).
+// A top level key which contains dots or brackets can also be used as-is.
+func inventoryValue(inventory map[string]interface{}, keyPath string) (interface{}, error) {
+	if val, isFound := inventory[keyPath]; isFound {
+		return val, nil
+	}
+	return configs.InventoryValue(inventory, keyPath)
+}
+
+// hasInventoryValue reports whether there is a value at the keyPath (see inventoryValue)
+func hasInventoryValue(inventory map[string]interface{}, keyPath string) (bool, error) {
+	if _, isFound := inventory[keyPath]; isFound {
+		return true, nil
+	}
+	return configs.HasValueAtPath(inventory, keyPath)
+}
+
+// ------------------------------------------------------------
+// Utilify functions
+// ------------------------------------------------------------
+
+// listItems returns the items of a list (slice or array) of any type, and false if value is not a list.
+func listItems(value interface{}) ([]interface{}, bool) {
+	if value == nil {
+		return nil, false
+	}
+	if items, isList := value.([]interface{}); isList {
+		return items, true
+	}
+
+	listValue := reflect.ValueOf(value)
+	if listValue.Kind() != reflect.Slice && listValue.Kind() != reflect.Array {
+		return nil, false
+	}
+	items := make([]interface{}, listValue.Len())
+	for idx := range items {
+		items[idx] = listValue.Index(idx).Interface()
+	}
+	return items, true
+}
+
+func yamlFn(obj interface{}) (string, error) {
+	bytes, err := yaml.Marshal(obj)
+	if err != nil {
+		return "", errors.Errorf("Failed to generate yaml for object, error: %s", err)
+	}
+	return string(bytes), nil
+}
+
+func indentWithSpaces(indentSpaceCharCount int, s string) string {
+	if len(s) < 1 {
+		return ""
+	}
+
+	indentationString := strings.Repeat(" ", indentSpaceCharCount)
+
+	lines := strings.SplitAfter(s, "\n")
+	return indentationString + strings.Join(lines, indentationString)
+}
+
+// ------------------------------------------------------------
+// Arithmetic functions
+// Based on https://github.com/hashicorp/consul-template/blob/9ef7c22f1ec0540ef746d0ecf873353ae57c4e77/template/funcs.go#L956
+// ------------------------------------------------------------
+
+// add returns the sum of a and b.
+// Based on https://github.com/hashicorp/consul-template/blob/9ef7c22f1ec0540ef746d0ecf873353ae57c4e77/template/funcs.go#L956
+func add(b, a interface{}) (interface{}, error) {
+	av := reflect.ValueOf(a)
+	bv := reflect.ValueOf(b)
+
+	switch av.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch bv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return av.Int() + bv.Int(), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return av.Int() + int64(bv.Uint()), nil
+		case reflect.Float32, reflect.Float64:
+			return float64(av.Int()) + bv.Float(), nil
+		default:
+			return nil, fmt.Errorf("add: unknown type for %q (%T)", bv, b)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch bv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return int64(av.Uint()) + bv.Int(), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return av.Uint() + bv.Uint(), nil
+		case reflect.Float32, reflect.Float64:
+			return float64(av.Uint()) + bv.Float(), nil
+		default:
+			return nil, fmt.Errorf("add: unknown type for %q (%T)", bv, b)
+		}
+	case reflect.Float32, reflect.Float64:
+		switch bv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return av.Float() + float64(bv.Int()), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return av.Float() + float64(bv.Uint()), nil
+		case reflect.Float32, reflect.Float64:
+			return av.Float() + bv.Float(), nil
+		default:
+			return nil, fmt.Errorf("add: unknown type for %q (%T)", bv, b)
+		}
+	default:
+		return nil, fmt.Errorf("add: unknown type for %q (%T)", av, a)
+	}
+}
+
+// subtract returns the difference of b from a.
+// Based on https://github.com/hashicorp/consul-template/blob/9ef7c22f1ec0540ef746d0ecf873353ae57c4e77/template/funcs.go#L956
+func subtract(b, a interface{}) (interface{}, error) {
+	av := reflect.ValueOf(a)
+	bv := reflect.ValueOf(b)
+
+	switch av.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch bv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return av.Int() - bv.Int(), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return av.Int() - int64(bv.Uint()), nil
+		case reflect.Float32, reflect.Float64:
+			return float64(av.Int()) - bv.Float(), nil
+		default:
+			return nil, fmt.Errorf("subtract: unknown type for %q (%T)", bv, b)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch bv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return int64(av.Uint()) - bv.Int(), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return av.Uint() - bv.Uint(), nil
+		case reflect.Float32, reflect.Float64:
+			return float64(av.Uint()) - bv.Float(), nil
+		default:
+			return nil, fmt.Errorf("subtract: unknown type for %q (%T)", bv, b)
+		}
+	case reflect.Float32, reflect.Float64:
+		switch bv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return av.Float() - float64(bv.Int()), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return av.Float() - float64(bv.Uint()), nil
+		case reflect.Float32, reflect.Float64:
+			return av.Float() - bv.Float(), nil
+		default:
+			return nil, fmt.Errorf("subtract: unknown type for %q (%T)", bv, b)
+		}
+	default:
+		return nil, fmt.Errorf("subtract: unknown type for %q (%T)", av, a)
+	}
+}
+
+// multiply returns the product of a and b.
+// Based on https://github.com/hashicorp/consul-template/blob/9ef7c22f1ec0540ef746d0ecf873353ae57c4e77/template/funcs.go#L956
+func multiply(b, a interface{}) (interface{}, error) {
+	av := reflect.ValueOf(a)
+	bv := reflect.ValueOf(b)
+
+	switch av.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch bv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return av.Int() * bv.Int(), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return av.Int() * int64(bv.Uint()), nil
+		case reflect.Float32, reflect.Float64:
+			return float64(av.Int()) * bv.Float(), nil
+		default:
+			return nil, fmt.Errorf("multiply: unknown type for %q (%T)", bv, b)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch bv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return int64(av.Uint()) * bv.Int(), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return av.Uint() * bv.Uint(), nil
+		case reflect.Float32, reflect.Float64:
+			return float64(av.Uint()) * bv.Float(), nil
+		default:
+			return nil, fmt.Errorf("multiply: unknown type for %q (%T)", bv, b)
+		}
+	case reflect.Float32, reflect.Float64:
+		switch bv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return av.Float() * float64(bv.Int()), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return av.Float() * float64(bv.Uint()), nil
+		case reflect.Float32, reflect.Float64:
+			return av.Float() * bv.Float(), nil
+		default:
+			return nil, fmt.Errorf("multiply: unknown type for %q (%T)", bv, b)
+		}
+	default:
+		return nil, fmt.Errorf("multiply: unknown type for %q (%T)", av, a)
+	}
+}
+
+// divide returns the division of b from a.
+// Based on https://github.com/hashicorp/consul-template/blob/9ef7c22f1ec0540ef746d0ecf873353ae57c4e77/template/funcs.go#L956
+func divide(b, a interface{}) (interface{}, error) {
+	av := reflect.ValueOf(a)
+	bv := reflect.ValueOf(b)
+
+	switch av.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch bv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return av.Int() / bv.Int(), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return av.Int() / int64(bv.Uint()), nil
+		case reflect.Float32, reflect.Float64:
+			return float64(av.Int()) / bv.Float(), nil
+		default:
+			return nil, fmt.Errorf("divide: unknown type for %q (%T)", bv, b)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch bv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return int64(av.Uint()) / bv.Int(), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return av.Uint() / bv.Uint(), nil
+		case reflect.Float32, reflect.Float64:
+			return float64(av.Uint()) / bv.Float(), nil
+		default:
+			return nil, fmt.Errorf("divide: unknown type for %q (%T)", bv, b)
+		}
+	case reflect.Float32, reflect.Float64:
+		switch bv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return av.Float() / float64(bv.Int()), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return av.Float() / float64(bv.Uint()), nil
+		case reflect.Float32, reflect.Float64:
+			return av.Float() / bv.Float(), nil
+		default:
+			return nil, fmt.Errorf("divide: unknown type for %q (%T)", bv, b)
+		}
+	default:
+		return nil, fmt.Errorf("divide: unknown type for %q (%T)", av, a)
+	}
+}
+
+// modulo returns the modulo of b from a.
+// Based on https://github.com/hashicorp/consul-template/blob/9ef7c22f1ec0540ef746d0ecf873353ae57c4e77/template/funcs.go#L956
+func modulo(b, a interface{}) (interface{}, error) {
+	av := reflect.ValueOf(a)
+	bv := reflect.ValueOf(b)
+
+	switch av.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch bv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return av.Int() % bv.Int(), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return av.Int() % int64(bv.Uint()), nil
+		default:
+			return nil, fmt.Errorf("modulo: unknown type for %q (%T)", bv, b)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch bv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return int64(av.Uint()) % bv.Int(), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return av.Uint() % bv.Uint(), nil
+		default:
+			return nil, fmt.Errorf("modulo: unknown type for %q (%T)", bv, b)
+		}
+	default:
+		return nil, fmt.Errorf("modulo: unknown type for %q (%T)", av, a)
+	}
+}
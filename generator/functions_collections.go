@@ -0,0 +1,490 @@
+package generator
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"text/template"
+
+	"github.com/bitrise-io/gotgen/configs"
+	"github.com/pkg/errors"
+)
+
+// collectionFunctions returns the list and dictionary template functions.
+// The names and argument order are the same as in Sprig (https://masterminds.github.io/sprig/lists.html and dicts.html).
+//
+// The dictionary functions accept maps with string keys (e.g. the inventory's and fromJson's maps),
+// and maps with interface{} keys (e.g. decoded from YAML by another library).
+// Like in Sprig set, unset, merge and mergeOverwrite modify the map in place, every other function returns a new map or list.
+// Every template is rendered with its own copy of the inventory (see Generator.execute), so the changes are only visible in the template which made them.
+// Unlike in Sprig keys and values return the items in the order of the keys, so that the generated content is stable.
+func collectionFunctions() template.FuncMap {
+	return template.FuncMap{
+		"list":           list,
+		"dict":           dict,
+		"get":            get,
+		"set":            set,
+		"unset":          unset,
+		"hasKey":         hasKey,
+		"keys":           keys,
+		"values":         values,
+		"merge":          merge,
+		"mergeOverwrite": mergeOverwrite,
+		"pick":           pick,
+		"omit":           omit,
+		"append":         appendFn,
+		"prepend":        prepend,
+		"first":          first,
+		"last":           last,
+		"rest":           rest,
+		"uniq":           uniq,
+		"sortAlpha":      sortAlpha,
+		"reverse":        reverse,
+		"has":            has,
+		"slice":          slice,
+	}
+}
+
+// ------------------------------------------------------------
+// Dictionary functions
+// ------------------------------------------------------------
+
+// dictValue returns the reflect.Value of a map with string or interface{} keys
+func dictValue(d interface{}) (reflect.Value, error) {
+	mapValue := reflect.ValueOf(d)
+	if mapValue.Kind() != reflect.Map {
+		return reflect.Value{}, errors.Errorf("Not a dictionary: %T", d)
+	}
+	if keyKind := mapValue.Type().Key().Kind(); keyKind != reflect.String && keyKind != reflect.Interface {
+		return reflect.Value{}, errors.Errorf("Not a dictionary with string keys: %T", d)
+	}
+	return mapValue, nil
+}
+
+// dictKeyValue returns the key as a reflect.Value which can be used to index the map.
+// If the map has interface{} keys, and has a key which is not a string (e.g. the 1 key of a map decoded from YAML)
+// but has the same string form, that key is returned.
+func dictKeyValue(mapValue reflect.Value, key string) reflect.Value {
+	keyType := mapValue.Type().Key()
+	if keyType.Kind() == reflect.String {
+		return reflect.ValueOf(key).Convert(keyType)
+	}
+
+	keyValue := reflect.ValueOf(key)
+	if mapValue.MapIndex(keyValue).IsValid() {
+		return keyValue
+	}
+	for _, aKey := range mapValue.MapKeys() {
+		if fmt.Sprintf("%v", aKey.Interface()) == key {
+			return aKey
+		}
+	}
+	return keyValue
+}
+
+// sortedDictKeyValues returns the keys of the map, in the alphabetical order of their string form
+func sortedDictKeyValues(mapValue reflect.Value) []reflect.Value {
+	keyValues := mapValue.MapKeys()
+	sort.Slice(keyValues, func(i, j int) bool {
+		return fmt.Sprintf("%v", keyValues[i].Interface()) < fmt.Sprintf("%v", keyValues[j].Interface())
+	})
+	return keyValues
+}
+
+// sortedDictKeys returns the keys of the map as strings, in alphabetical order
+func sortedDictKeys(mapValue reflect.Value) []string {
+	keys := []string{}
+	for _, aKey := range sortedDictKeyValues(mapValue) {
+		keys = append(keys, fmt.Sprintf("%v", aKey.Interface()))
+	}
+	return keys
+}
+
+// toDict returns the map as a map[string]interface{}: the map itself if it already is one,
+// otherwise a normalized copy (see configs.NormalizeValue).
+func toDict(d interface{}) (map[string]interface{}, error) {
+	if typedDict, isDict := d.(map[string]interface{}); isDict {
+		return typedDict, nil
+	}
+	mapValue, err := dictValue(d)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	converted := make(map[string]interface{}, mapValue.Len())
+	for _, aKey := range mapValue.MapKeys() {
+		converted[fmt.Sprintf("%v", aKey.Interface())] = configs.NormalizeValue(mapValue.MapIndex(aKey).Interface())
+	}
+	return converted, nil
+}
+
+// dict creates a map from key value pairs, e.g. dict "name" "api" "port" 80.
+// The keys are converted to strings, the value of the last key is an empty string if it's missing.
+func dict(keyValues ...interface{}) map[string]interface{} {
+	d := map[string]interface{}{}
+	for idx := 0; idx < len(keyValues); idx += 2 {
+		key := toString(keyValues[idx])
+		if idx+1 < len(keyValues) {
+			d[key] = keyValues[idx+1]
+		} else {
+			d[key] = ""
+		}
+	}
+	return d
+}
+
+// get returns the value of the key, or an empty string if the map has no such key
+func get(d interface{}, key string) (interface{}, error) {
+	mapValue, err := dictValue(d)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	value := mapValue.MapIndex(dictKeyValue(mapValue, key))
+	if !value.IsValid() {
+		return "", nil
+	}
+	return value.Interface(), nil
+}
+
+// set sets the value of the key in the map, and returns the map
+func set(d interface{}, key string, value interface{}) (interface{}, error) {
+	mapValue, err := dictValue(d)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if mapValue.IsNil() {
+		return nil, errors.New("Can't set a key of a nil dictionary")
+	}
+
+	elemType := mapValue.Type().Elem()
+	var val reflect.Value
+	if value == nil {
+		val = reflect.Zero(elemType)
+	} else {
+		val = reflect.ValueOf(value)
+		if !val.Type().AssignableTo(elemType) {
+			return nil, errors.Errorf("Can't set %s to a %T value in a %T dictionary", key, value, d)
+		}
+	}
+	mapValue.SetMapIndex(dictKeyValue(mapValue, key), val)
+	return d, nil
+}
+
+// unset removes the key from the map, and returns the map
+func unset(d interface{}, key string) (interface{}, error) {
+	mapValue, err := dictValue(d)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if !mapValue.IsNil() {
+		mapValue.SetMapIndex(dictKeyValue(mapValue, key), reflect.Value{})
+	}
+	return d, nil
+}
+
+// hasKey reports whether the map has the key
+func hasKey(d interface{}, key string) (bool, error) {
+	mapValue, err := dictValue(d)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	return mapValue.MapIndex(dictKeyValue(mapValue, key)).IsValid(), nil
+}
+
+// keys returns the keys of the maps, in alphabetical order, without duplicates
+func keys(dicts ...interface{}) ([]interface{}, error) {
+	keySet := map[string]bool{}
+	for _, aDict := range dicts {
+		mapValue, err := dictValue(aDict)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		for _, aKey := range sortedDictKeys(mapValue) {
+			keySet[aKey] = true
+		}
+	}
+
+	sortedKeys := make([]string, 0, len(keySet))
+	for aKey := range keySet {
+		sortedKeys = append(sortedKeys, aKey)
+	}
+	sort.Strings(sortedKeys)
+
+	keyList := make([]interface{}, len(sortedKeys))
+	for idx, aKey := range sortedKeys {
+		keyList[idx] = aKey
+	}
+	return keyList, nil
+}
+
+// values returns the values of the map, in the order of their keys
+func values(d interface{}) ([]interface{}, error) {
+	mapValue, err := dictValue(d)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	valueList := []interface{}{}
+	for _, aKey := range sortedDictKeyValues(mapValue) {
+		valueList = append(valueList, mapValue.MapIndex(aKey).Interface())
+	}
+	return valueList, nil
+}
+
+// merge deep merges the src maps into dst, and returns dst. The values already in dst are kept.
+// If dst is not a map[string]interface{} a new map is returned, and dst is not modified.
+func merge(dst interface{}, srcs ...interface{}) (interface{}, error) {
+	return mergeDicts(dst, srcs, false)
+}
+
+// mergeOverwrite deep merges the src maps into dst, and returns dst. The values of the src maps override the values in dst,
+// the later src maps override the earlier ones.
+// If dst is not a map[string]interface{} a new map is returned, and dst is not modified.
+func mergeOverwrite(dst interface{}, srcs ...interface{}) (interface{}, error) {
+	return mergeDicts(dst, srcs, true)
+}
+
+func mergeDicts(dst interface{}, srcs []interface{}, isOverwrite bool) (interface{}, error) {
+	dstDict, err := toDict(dst)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, aSrc := range srcs {
+		srcDict, err := toDict(aSrc)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		mergeDictInto(dstDict, srcDict, isOverwrite)
+	}
+	return dstDict, nil
+}
+
+// mergeDictInto deep merges src into dst: maps are merged recursively,
+// every other value is only set if dst has no such key or if isOverwrite is true.
+func mergeDictInto(dst, src map[string]interface{}, isOverwrite bool) {
+	for key, srcValue := range src {
+		dstValue, isFound := dst[key]
+		if !isFound {
+			dst[key] = srcValue
+			continue
+		}
+
+		dstMap, isDstMap := configs.NormalizeValue(dstValue).(map[string]interface{})
+		srcMap, isSrcMap := configs.NormalizeValue(srcValue).(map[string]interface{})
+		if isDstMap && isSrcMap {
+			if _, isSameType := dstValue.(map[string]interface{}); isSameType {
+				dstMap = dstValue.(map[string]interface{})
+			}
+			mergeDictInto(dstMap, srcMap, isOverwrite)
+			dst[key] = dstMap
+			continue
+		}
+
+		if isOverwrite {
+			dst[key] = srcValue
+		}
+	}
+}
+
+// pick returns a new map with only the specified keys of the map
+func pick(d interface{}, keys ...string) (map[string]interface{}, error) {
+	dictCopy, err := copyDict(d)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	picked := map[string]interface{}{}
+	for _, aKey := range keys {
+		if value, isFound := dictCopy[aKey]; isFound {
+			picked[aKey] = value
+		}
+	}
+	return picked, nil
+}
+
+// omit returns a new map without the specified keys of the map
+func omit(d interface{}, keys ...string) (map[string]interface{}, error) {
+	dictCopy, err := copyDict(d)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, aKey := range keys {
+		delete(dictCopy, aKey)
+	}
+	return dictCopy, nil
+}
+
+// copyDict returns a shallow copy of the map as a map[string]interface{}
+func copyDict(d interface{}) (map[string]interface{}, error) {
+	dictValue, err := toDict(d)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	dictCopy := make(map[string]interface{}, len(dictValue))
+	for key, value := range dictValue {
+		dictCopy[key] = value
+	}
+	return dictCopy, nil
+}
+
+// ------------------------------------------------------------
+// List functions
+// ------------------------------------------------------------
+
+// toList returns the items of a list of any type (see listItems), or an error if l is not a list
+func toList(l interface{}) ([]interface{}, error) {
+	items, isList := listItems(l)
+	if !isList {
+		return nil, errors.Errorf("Not a list: %T", l)
+	}
+	return items, nil
+}
+
+// list creates a list from its arguments
+func list(items ...interface{}) []interface{} {
+	return append([]interface{}{}, items...)
+}
+
+// appendFn returns a new list with the value appended to the end of the list
+func appendFn(l interface{}, value interface{}) ([]interface{}, error) {
+	items, err := toList(l)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return append(append([]interface{}{}, items...), value), nil
+}
+
+// prepend returns a new list with the value added to the beginning of the list
+func prepend(l interface{}, value interface{}) ([]interface{}, error) {
+	items, err := toList(l)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return append([]interface{}{value}, items...), nil
+}
+
+// first returns the first item of the list, or nil if the list is empty
+func first(l interface{}) (interface{}, error) {
+	items, err := toList(l)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(items) < 1 {
+		return nil, nil
+	}
+	return items[0], nil
+}
+
+// last returns the last item of the list, or nil if the list is empty
+func last(l interface{}) (interface{}, error) {
+	items, err := toList(l)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(items) < 1 {
+		return nil, nil
+	}
+	return items[len(items)-1], nil
+}
+
+// rest returns every item of the list except the first one
+func rest(l interface{}) ([]interface{}, error) {
+	items, err := toList(l)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(items) < 1 {
+		return []interface{}{}, nil
+	}
+	return append([]interface{}{}, items[1:]...), nil
+}
+
+// uniq returns a new list without the duplicated items, keeping the first occurrence of every item
+func uniq(l interface{}) ([]interface{}, error) {
+	items, err := toList(l)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	uniqItems := []interface{}{}
+	for _, anItem := range items {
+		if !containsItem(uniqItems, anItem) {
+			uniqItems = append(uniqItems, anItem)
+		}
+	}
+	return uniqItems, nil
+}
+
+// sortAlpha returns the items of the list converted to strings, in alphabetical order
+func sortAlpha(l interface{}) ([]string, error) {
+	if _, err := toList(l); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	strs := toStrings(l)
+	sort.Strings(strs)
+	return strs, nil
+}
+
+// reverse returns a new list with the items of the list in reverse order
+func reverse(l interface{}) ([]interface{}, error) {
+	items, err := toList(l)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	reversed := make([]interface{}, len(items))
+	for idx, anItem := range items {
+		reversed[len(items)-1-idx] = anItem
+	}
+	return reversed, nil
+}
+
+// has reports whether the list contains the needle
+func has(needle interface{}, l interface{}) (bool, error) {
+	items, err := toList(l)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	return containsItem(items, needle), nil
+}
+
+// slice returns the items of the list from the start index (inclusive) to the end index (exclusive),
+// e.g. slice $list 1 3. Both indexes are optional. Strings can be sliced too, like with text/template's slice.
+func slice(l interface{}, indexes ...int) (interface{}, error) {
+	if len(indexes) > 2 {
+		return nil, errors.Errorf("Wrong number of indexes: %d, expected at most 2", len(indexes))
+	}
+
+	var length int
+	items, isList := listItems(l)
+	str, isString := l.(string)
+	switch {
+	case isList:
+		length = len(items)
+	case isString:
+		length = len(str)
+	default:
+		return nil, errors.Errorf("Not a list: %T", l)
+	}
+
+	start, end := 0, length
+	if len(indexes) > 0 {
+		start = indexes[0]
+	}
+	if len(indexes) > 1 {
+		end = indexes[1]
+	}
+	if start < 0 || end > length || start > end {
+		return nil, errors.Errorf("Slice indexes out of range: [%d:%d] with length %d", start, end, length)
+	}
+
+	if isString {
+		return str[start:end], nil
+	}
+	return append([]interface{}{}, items[start:end]...), nil
+}
+
+func containsItem(items []interface{}, item interface{}) bool {
+	for _, anItem := range items {
+		if reflect.DeepEqual(anItem, item) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_collectionFunctions(t *testing.T) {
+	newInventory := func() map[string]interface{} {
+		return map[string]interface{}{
+			"Service": map[string]interface{}{"name": "api", "port": 8080, "secret": "s3cr3t"},
+			"YAML":    map[interface{}]interface{}{"name": "web", "nested": map[interface{}]interface{}{"a": 1}},
+			"Hosts":   []interface{}{"a", "b", "c", "d"},
+			"Tags":    []string{"b", "a", "b", "c"},
+			"IntKeys": map[interface{}]interface{}{2: "b", 1: "a", "c": "c"},
+		}
+	}
+
+	for tmpl, expected := range map[string]string{
+		`{{ list 1 "two" true }}`:                    "[1 two true]",
+		`{{ dict "name" "api" "port" 80 | toJson }}`: `{"name":"api","port":80}`,
+		`{{ dict "name" | toJson }}`:                 `{"name":""}`,
+		`{{ get .Service "name" }}|{{ get .YAML "name" }}|{{ get .Service "missing" }}`: "api|web|",
+		`{{ hasKey .Service "port" }} {{ hasKey .YAML "name" }} {{ hasKey .YAML "x" }}`: "true true false",
+		`{{ $_ := set .Service "port" 80 }}{{ .Service.port }}`:                         "80",
+		`{{ $_ := set .YAML "name" "site" }}{{ get .YAML "name" }}`:                     "site",
+		`{{ $_ := unset .Service "secret" }}{{ keys .Service }}`:                        "[name port]",
+		`{{ keys .Service .YAML }}`:                                                     "[name nested port secret]",
+		`{{ values .Service }}`:                                                         "[api 8080 s3cr3t]",
+		`{{ merge (dict "name" "x") .Service | toJson }}`:                               `{"name":"x","port":8080,"secret":"s3cr3t"}`,
+		`{{ mergeOverwrite (dict "name" "x") .Service | toJson }}`:                      `{"name":"api","port":8080,"secret":"s3cr3t"}`,
+		`{{ merge (dict "nested" (dict "b" 2)) .YAML | toJson }}`:                       `{"name":"web","nested":{"a":1,"b":2}}`,
+		`{{ $d := dict "a" 1 }}{{ $_ := merge $d (dict "b" 2) }}{{ $d | toJson }}`:      `{"a":1,"b":2}`,
+		`{{ pick .Service "name" "missing" | toJson }}`:                                 `{"name":"api"}`,
+		`{{ omit .YAML "nested" | toJson }}`:                                            `{"name":"web"}`,
+		`{{ append .Hosts "e" }} {{ prepend .Tags "z" }} {{ .Hosts }}`:                  "[a b c d e] [z b a b c] [a b c d]",
+		`{{ first .Hosts }} {{ last .Hosts }} {{ rest .Hosts }} {{ rest list }}`:        "a d [b c d] []",
+		`{{ first list }}`: "<no value>",
+		`{{ .Tags | uniq }} {{ .Tags | sortAlpha }} {{ .Tags | reverse }}`: "[b a c] [a b b c] [c b a b]",
+		`{{ .Tags | uniq | sortAlpha | join "," }}`:                        "a,b,c",
+		`{{ has "b" .Tags }} {{ has "x" .Hosts }} {{ has 1 (list 1 2) }}`:  "true false true",
+		`{{ slice .Hosts 1 3 }} {{ slice .Hosts 2 }} {{ slice .Hosts }}`:   "[b c] [c d] [a b c d]",
+		`{{ slice "hello" 1 3 }}`:                                          "el",
+
+		// YAML maps can have keys which are not strings
+		`{{ values .IntKeys }} {{ keys .IntKeys }}`:                                           "[a b c] [1 2 c]",
+		`{{ get .IntKeys "1" }} {{ hasKey .IntKeys "2" }} {{ hasKey .IntKeys "3" }}`:          "a true false",
+		`{{ $_ := set .IntKeys "1" "x" }}{{ $_ := unset .IntKeys "2" }}{{ values .IntKeys }}`: "[x c]",
+	} {
+		gen := newTestGenerator(t, Options{Inventory: newInventory()})
+		genCont, err := gen.RenderString(tmpl)
+		require.NoError(t, err, tmpl)
+		require.Equal(t, expected, genCont, tmpl)
+	}
+
+	t.Log("Errors")
+	{
+		gen := newTestGenerator(t, Options{Inventory: newInventory()})
+		for _, tmpl := range []string{
+			`{{ get .Hosts "a" }}`,
+			`{{ keys .Service "a" }}`,
+			`{{ set (dict) "a" }}`,
+			`{{ merge .Service .Hosts }}`,
+			`{{ first .Service }}`,
+			`{{ has "a" "abc" }}`,
+			`{{ slice .Hosts 3 1 }}`,
+			`{{ slice .Hosts 1 10 }}`,
+			`{{ slice .Hosts 0 1 2 }}`,
+			`{{ slice 5 0 1 }}`,
+		} {
+			_, err := gen.RenderString(tmpl)
+			require.Error(t, err, tmpl)
+		}
+	}
+}
+
+func Test_collectionFunctions_inventoryIsNotShared(t *testing.T) {
+	inventory := map[string]interface{}{
+		"Nested": map[string]interface{}{"A": 1},
+		"YAML":   map[interface{}]interface{}{"A": 1},
+	}
+	gen := newTestGenerator(t, Options{Inventory: inventory})
+
+	genCont, err := gen.RenderString(`{{ $_ := set .Nested "Leaked" "yes" }}{{ $_ := unset .YAML "A" }}{{ $_ := merge .Nested (dict "B" 2) }}{{ .Nested | toJson }}`)
+	require.NoError(t, err)
+	require.Equal(t, `{"A":1,"B":2,"Leaked":"yes"}`, genCont)
+
+	// the changes made by a template are not visible in the next one
+	genCont, err = gen.RenderString(`{{ .Nested | toJson }} {{ .YAML | toJson }}`)
+	require.NoError(t, err)
+	require.Equal(t, `{"A":1} {"A":1}`, genCont)
+	require.Equal(t, map[string]interface{}{
+		"Nested": map[string]interface{}{"A": 1},
+		"YAML":   map[interface{}]interface{}{"A": 1},
+	}, inventory)
+}
+
+func Test_set(t *testing.T) {
+	t.Log("Typed map")
+	{
+		d := map[string]string{"a": "b"}
+		_, err := set(d, "c", "d")
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"a": "b", "c": "d"}, d)
+
+		_, err = set(d, "e", 1)
+		require.Error(t, err)
+	}
+
+	t.Log("nil value")
+	{
+		d := map[string]interface{}{"a": "b"}
+		_, err := set(d, "a", nil)
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"a": nil}, d)
+	}
+}
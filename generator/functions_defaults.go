@@ -0,0 +1,92 @@
+package generator
+
+import (
+	"reflect"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// defaultFunctions returns the template functions which handle optional values and enforce invariants.
+// The names and argument order are the same as in Sprig (https://masterminds.github.io/sprig/defaults.html),
+// the errors of required and fail are reported with the template file and line, like any other template error.
+func defaultFunctions() template.FuncMap {
+	return template.FuncMap{
+		"default":  defaultFn,
+		"coalesce": coalesce,
+		"empty":    empty,
+		"ternary":  ternary,
+		"required": required,
+		"fail":     fail,
+	}
+}
+
+// empty reports whether the value is empty: nil, false, 0, or an empty string, list or map.
+// A struct is never empty.
+func empty(value interface{}) bool {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		return true
+	}
+
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Complex64, reflect.Complex128:
+		return v.Complex() == 0
+	case reflect.Struct:
+		return false
+	}
+	return v.IsNil()
+}
+
+// defaultFn returns the value if it's not empty (see empty), otherwise the default value,
+// e.g. {{ .Port | default 8080 }}. The value is optional, so that it can be the result of a pipeline.
+func defaultFn(defaultValue interface{}, value ...interface{}) interface{} {
+	if len(value) < 1 || empty(value[0]) {
+		return defaultValue
+	}
+	return value[0]
+}
+
+// coalesce returns the first value which is not empty (see empty), or nil if every value is empty
+func coalesce(values ...interface{}) interface{} {
+	for _, aValue := range values {
+		if !empty(aValue) {
+			return aValue
+		}
+	}
+	return nil
+}
+
+// ternary returns trueValue if condition is true, otherwise falseValue, e.g. {{ .IsProd | ternary "prod" "dev" }}
+func ternary(trueValue, falseValue interface{}, condition bool) interface{} {
+	if condition {
+		return trueValue
+	}
+	return falseValue
+}
+
+// required returns the value, or fails with the message if the value is nil or an empty string
+func required(message string, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, errors.New(message)
+	}
+	if str, isString := value.(string); isString && str == "" {
+		return nil, errors.New(message)
+	}
+	return value, nil
+}
+
+// fail fails with the message
+func fail(message string) (string, error) {
+	return "", errors.New(message)
+}
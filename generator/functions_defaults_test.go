@@ -0,0 +1,59 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_defaultFunctions(t *testing.T) {
+	gen := newTestGenerator(t, Options{Inventory: map[string]interface{}{
+		"Port":   8080,
+		"Zero":   0,
+		"Empty":  "",
+		"List":   []interface{}{},
+		"Map":    map[string]interface{}{"Key": "value"},
+		"IsProd": true,
+		"Nil":    nil,
+	}})
+
+	for tmpl, expected := range map[string]string{
+		`{{ .Port | default 80 }} {{ .Zero | default 80 }} {{ default "x" .Empty }} {{ default "x" .Nil }}`: "8080 80 x x",
+		`{{ .List | default "none" }} {{ .Map | default "none" }}`:                                          "none map[Key:value]",
+		`{{ coalesce .Empty .Zero .Port }} {{ coalesce .Empty .Nil }}`:                                      "8080 <no value>",
+		`{{ empty .Zero }} {{ empty .List }} {{ empty .Nil }} {{ empty .Port }} {{ empty .Map }}`:           "true true true false false",
+		`{{ .IsProd | ternary "prod" "dev" }} {{ ternary "prod" "dev" false }}`:                             "prod dev",
+		`{{ required "Port is required" .Port }} {{ required "Zero is required" .Zero }}`:                   "8080 0",
+	} {
+		genCont, err := gen.RenderString(tmpl)
+		require.NoError(t, err, tmpl)
+		require.Equal(t, expected, genCont, tmpl)
+	}
+
+	t.Log("required and fail: the error is reported with the template file and line")
+	{
+		_, err := gen.RenderTemplate("conf.yml.gg", "---gotgen\ninventory:\n  Other: 1\n---\nport: {{ .Port }}\nhost: {{ required \"Host is required\" .Empty }}\n")
+		require.EqualError(t, err, `template: conf.yml.gg:6:9: executing "conf.yml.gg" at <required "Host is required" .Empty>: error calling required: Host is required`)
+
+		_, err = gen.RenderTemplate("conf.yml.gg", "{{ if .IsProd }}\n{{ fail \"Not supported in prod\" }}\n{{ end }}")
+		require.EqualError(t, err, `template: conf.yml.gg:2:3: executing "conf.yml.gg" at <fail "Not supported in prod">: error calling fail: Not supported in prod`)
+
+		_, err = gen.RenderTemplate("conf.yml.gg", "---gotgen\ndelimiter:\n  left: \"[[\"\n  right: \"]]\"\n---\n[[ required \"Nil is required\" .Nil ]]")
+		require.EqualError(t, err, `template: conf.yml.gg:6:3: executing "conf.yml.gg" at <required "Nil is required" .Nil>: error calling required: Nil is required`)
+
+		_, err = gen.RenderTemplate("conf.yml.gg", "---gotgen\noutput: other.yml\n---\nport: {{ .Port\n")
+		require.EqualError(t, err, "template: conf.yml.gg:5: unclosed action started at conf.yml.gg:4")
+	}
+}
+
+func Test_empty(t *testing.T) {
+	require.True(t, empty(nil))
+	require.True(t, empty(false))
+	require.True(t, empty(uint(0)))
+	require.True(t, empty(0.0))
+	require.True(t, empty(map[interface{}]interface{}{}))
+	require.True(t, empty((*int)(nil)))
+	require.False(t, empty(struct{}{}))
+	require.False(t, empty("a"))
+	require.False(t, empty(-1))
+}
@@ -0,0 +1,110 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/bitrise-io/gotgen/configs"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// defaultPrettyJSONIndent is the indentation of toPrettyJson, if not specified
+const defaultPrettyJSONIndent = 2
+
+// encodingFunctions returns the JSON and YAML encoding and decoding template functions
+func encodingFunctions() template.FuncMap {
+	return template.FuncMap{
+		"toJson":       toJSON,
+		"toPrettyJson": toPrettyJSON,
+		"fromJson":     fromJSON,
+		"fromYaml":     fromYAML,
+	}
+}
+
+// toJSON encodes obj as a single line JSON string
+func toJSON(obj interface{}) (string, error) {
+	bytes, err := json.Marshal(configs.NormalizeValue(obj))
+	if err != nil {
+		return "", errors.Errorf("Failed to generate json for object, error: %s", err)
+	}
+	return string(bytes), nil
+}
+
+// toPrettyJSON encodes the last argument as an indented JSON string.
+// The indentation can be specified before the object, as the number of spaces or as a string (e.g. "\t"),
+// e.g. {{ toPrettyJson .Obj }}, {{ toPrettyJson 4 .Obj }} or {{ .Obj | toPrettyJson "\t" }}. Defaults to 2 spaces.
+func toPrettyJSON(args ...interface{}) (string, error) {
+	indent := strings.Repeat(" ", defaultPrettyJSONIndent)
+	switch len(args) {
+	case 1:
+	case 2:
+		switch typedIndent := args[0].(type) {
+		case int:
+			indent = strings.Repeat(" ", typedIndent)
+		case string:
+			indent = typedIndent
+		default:
+			return "", errors.Errorf("Invalid indentation (%v), has to be the number of spaces or a string, but it is %T", args[0], args[0])
+		}
+	default:
+		return "", errors.Errorf("Wrong number of arguments: %d, expected the object and optionally the indentation before it", len(args))
+	}
+
+	bytes, err := json.MarshalIndent(configs.NormalizeValue(args[len(args)-1]), "", indent)
+	if err != nil {
+		return "", errors.Errorf("Failed to generate json for object, error: %s", err)
+	}
+	return string(bytes), nil
+}
+
+// fromJSON decodes a JSON string. Integer numbers are decoded as int (like in the inventory), every other number as float64.
+func fromJSON(s string) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(s)))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, errors.Errorf("Failed to parse json, error: %s", err)
+	}
+	// only accept it if the whole input was a single JSON value
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("Failed to parse json, error: unexpected content after the JSON value")
+	}
+	return convertJSONNumbers(value), nil
+}
+
+// convertJSONNumbers recursively converts the json.Number values to int, or if not an integer to float64
+func convertJSONNumbers(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case json.Number:
+		if intValue, err := strconv.ParseInt(typedValue.String(), 10, 0); err == nil {
+			return int(intValue)
+		}
+		floatValue, _ := typedValue.Float64()
+		return floatValue
+	case map[string]interface{}:
+		for key, val := range typedValue {
+			typedValue[key] = convertJSONNumbers(val)
+		}
+	case []interface{}:
+		for idx, val := range typedValue {
+			typedValue[idx] = convertJSONNumbers(val)
+		}
+	}
+	return value
+}
+
+// fromYAML decodes a YAML string. The maps are decoded as map[string]interface{} (see configs.NormalizeValue),
+// the same type the inventory and fromJson use.
+func fromYAML(s string) (interface{}, error) {
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(s), &value); err != nil {
+		return nil, errors.Errorf("Failed to parse yaml, error: %s", err)
+	}
+	return configs.NormalizeValue(value), nil
+}
@@ -0,0 +1,47 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_encodingFunctions(t *testing.T) {
+	gen := newTestGenerator(t, Options{Inventory: map[string]interface{}{
+		"Obj":  map[string]interface{}{"b": []interface{}{1, "two"}, "a": true},
+		"YAML": map[interface{}]interface{}{"key": "value"},
+		"JSON": `{"port": 8080, "ratio": 0.5, "big": 1000000, "hosts": ["a", "b"]}`,
+	}})
+
+	for tmpl, expected := range map[string]string{
+		`{{ toJson .Obj }}`:             `{"a":true,"b":[1,"two"]}`,
+		`{{ .YAML | toJson }}`:          `{"key":"value"}`,
+		`{{ toPrettyJson .Obj }}`:       "{\n  \"a\": true,\n  \"b\": [\n    1,\n    \"two\"\n  ]\n}",
+		`{{ .Obj | toPrettyJson 4 }}`:   "{\n    \"a\": true,\n    \"b\": [\n        1,\n        \"two\"\n    ]\n}",
+		`{{ toPrettyJson "\t" .YAML }}`: "{\n\t\"key\": \"value\"\n}",
+		`{{ toPrettyJson 3 }}`:          "3",
+		`{{ $c := fromJson .JSON }}{{ $c.port }} {{ $c.ratio }} {{ $c.big }} {{ index $c.hosts 1 }} {{ $c.port | add 1 }}`: "8080 0.5 1000000 b 8081",
+		`{{ (fromYaml "a:\n  b: [1, 2]").a.b }}`: "[1 2]",
+		`{{ fromYaml "a:\n  b: c" | toJson }}`:   `{"a":{"b":"c"}}`,
+	} {
+		genCont, err := gen.RenderString(tmpl)
+		require.NoError(t, err, tmpl)
+		require.Equal(t, expected, genCont, tmpl)
+	}
+
+	t.Log("Errors")
+	{
+		for _, tmpl := range []string{
+			`{{ fromJson "{invalid" }}`,
+			`{{ fromJson "{} {}" }}`,
+			`{{ fromJson "1 ]" }}`,
+			`{{ fromJson "{} }" }}`,
+			`{{ fromYaml "a: [" }}`,
+			`{{ toPrettyJson true .Obj }}`,
+			`{{ toPrettyJson 1 2 .Obj }}`,
+		} {
+			_, err := gen.RenderString(tmpl)
+			require.Error(t, err, tmpl)
+		}
+	}
+}
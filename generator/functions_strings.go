@@ -0,0 +1,223 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// stringFunctions returns the string template functions.
+// The names and argument order are the same as in Sprig (https://masterminds.github.io/sprig/strings.html),
+// the string being operated on is always the last argument, so that the functions can be used in pipelines,
+// e.g. {{ .Name | replace "-" "_" | upper }}.
+func stringFunctions() template.FuncMap {
+	return template.FuncMap{
+		"upper":     strings.ToUpper,
+		"lower":     strings.ToLower,
+		"title":     strings.Title,
+		"trim":      strings.TrimSpace,
+		"replace":   replace,
+		"split":     split,
+		"splitList": splitList,
+		"join":      join,
+		"contains":  contains,
+		"hasPrefix": hasPrefix,
+		"hasSuffix": hasSuffix,
+		"repeat":    repeat,
+		"substr":    substr,
+		"trunc":     trunc,
+		"quote":     quote,
+		"squote":    squote,
+		"camelcase": camelcase,
+		"snakecase": snakecase,
+		"kebabcase": kebabcase,
+	}
+}
+
+// replace replaces every occurrence of old with new in s
+func replace(old, new, s string) string {
+	return strings.Replace(s, old, new, -1)
+}
+
+// split splits s by sep into a map with the keys _0, _1, ..., like Sprig's split,
+// so that the parts can be accessed by key, e.g. {{ (split "." .Version)._0 }}. See splitList for a list.
+func split(sep, s string) map[string]interface{} {
+	parts := map[string]interface{}{}
+	for idx, aPart := range strings.Split(s, sep) {
+		parts[fmt.Sprintf("_%d", idx)] = aPart
+	}
+	return parts
+}
+
+// splitList splits s by sep into a list
+func splitList(sep, s string) []interface{} {
+	parts := []interface{}{}
+	for _, aPart := range strings.Split(s, sep) {
+		parts = append(parts, aPart)
+	}
+	return parts
+}
+
+// join joins the items of a list with sep. The items are converted to strings, nil items are skipped.
+func join(sep string, list interface{}) string {
+	return strings.Join(toStrings(list), sep)
+}
+
+func contains(substr, s string) bool {
+	return strings.Contains(s, substr)
+}
+
+func hasPrefix(prefix, s string) bool {
+	return strings.HasPrefix(s, prefix)
+}
+
+func hasSuffix(suffix, s string) bool {
+	return strings.HasSuffix(s, suffix)
+}
+
+func repeat(count int, s string) string {
+	if count < 1 {
+		return ""
+	}
+	return strings.Repeat(s, count)
+}
+
+// substr returns the characters of s from start (inclusive) to end (exclusive).
+// A negative start means from the beginning, a negative end (or an end after the last character) means to the end.
+func substr(start, end int, s string) string {
+	runes := []rune(s)
+	if start < 0 {
+		start = 0
+	}
+	if end < 0 || end > len(runes) {
+		end = len(runes)
+	}
+	if start > end {
+		return ""
+	}
+	return string(runes[start:end])
+}
+
+// trunc returns the first length characters of s, or with a negative length the last -length characters.
+func trunc(length int, s string) string {
+	runes := []rune(s)
+	if length < 0 && len(runes)+length > 0 {
+		return string(runes[len(runes)+length:])
+	}
+	if length >= 0 && len(runes) > length {
+		return string(runes[:length])
+	}
+	return s
+}
+
+// quote wraps every argument in double quotes (escaping them as Go string literals), and joins them with spaces.
+// nil arguments are skipped.
+func quote(args ...interface{}) string {
+	quoted := []string{}
+	for _, anArg := range args {
+		if anArg != nil {
+			quoted = append(quoted, fmt.Sprintf("%q", toString(anArg)))
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+// squote wraps every argument in single quotes, and joins them with spaces. nil arguments are skipped.
+func squote(args ...interface{}) string {
+	quoted := []string{}
+	for _, anArg := range args {
+		if anArg != nil {
+			quoted = append(quoted, "'"+toString(anArg)+"'")
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+// camelcase converts s to CamelCase, e.g. http_server => HttpServer.
+// The first letter of every word is capitalized, the rest of the word is kept as-is.
+func camelcase(s string) string {
+	words := splitWords(s)
+	for idx, aWord := range words {
+		runes := []rune(aWord)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[idx] = string(runes)
+	}
+	return strings.Join(words, "")
+}
+
+// snakecase converts s to snake_case, e.g. FirstName => first_name
+func snakecase(s string) string {
+	return strings.ToLower(strings.Join(splitWords(s), "_"))
+}
+
+// kebabcase converts s to kebab-case, e.g. FirstName => first-name
+func kebabcase(s string) string {
+	return strings.ToLower(strings.Join(splitWords(s), "-"))
+}
+
+// splitWords splits s into words, at every character which is not a letter or a digit,
+// and at every lower to upper case change, e.g. "HTTPServer_name-2x" => ["HTTP", "Server", "name", "2x"].
+func splitWords(s string) []string {
+	words := []string{}
+	runes := []rune(s)
+	wordStart := -1
+	for idx, aRune := range runes {
+		if !unicode.IsLetter(aRune) && !unicode.IsDigit(aRune) {
+			if wordStart >= 0 {
+				words = append(words, string(runes[wordStart:idx]))
+				wordStart = -1
+			}
+			continue
+		}
+		if wordStart < 0 {
+			wordStart = idx
+			continue
+		}
+
+		prev := runes[idx-1]
+		isLowerToUpper := unicode.IsUpper(aRune) && (unicode.IsLower(prev) || unicode.IsDigit(prev))
+		// the last upper case letter of an acronym starts the next word, e.g. HTTPServer => HTTP Server
+		isAcronymEnd := unicode.IsUpper(aRune) && unicode.IsUpper(prev) && idx+1 < len(runes) && unicode.IsLower(runes[idx+1])
+		if isLowerToUpper || isAcronymEnd {
+			words = append(words, string(runes[wordStart:idx]))
+			wordStart = idx
+		}
+	}
+	if wordStart >= 0 {
+		words = append(words, string(runes[wordStart:]))
+	}
+	return words
+}
+
+// toString converts a template value to a string
+func toString(value interface{}) string {
+	switch typedValue := value.(type) {
+	case string:
+		return typedValue
+	case []byte:
+		return string(typedValue)
+	case fmt.Stringer:
+		return typedValue.String()
+	case error:
+		return typedValue.Error()
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// toStrings converts a list (of any type) to a list of strings, skipping the nil items.
+// Any other value is converted to a single item list.
+func toStrings(list interface{}) []string {
+	items, isList := listItems(list)
+	if !isList {
+		return []string{toString(list)}
+	}
+
+	strs := []string{}
+	for _, anItem := range items {
+		if anItem != nil {
+			strs = append(strs, toString(anItem))
+		}
+	}
+	return strs
+}
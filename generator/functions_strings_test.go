@@ -0,0 +1,44 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_stringFunctions(t *testing.T) {
+	gen := newTestGenerator(t, Options{Inventory: map[string]interface{}{
+		"Name":    "my-service",
+		"Version": "1.2.3",
+		"List":    []interface{}{"a", 1, nil, true},
+	}})
+
+	for tmpl, expected := range map[string]string{
+		`{{ "Hello" | upper }} {{ "Hello" | lower }} {{ "hello world" | title }}`: "HELLO hello Hello World",
+		`[{{ "  a b  " | trim }}]`:                                                "[a b]",
+		`{{ .Name | replace "-" "_" }}`:                                           "my_service",
+		`{{ (split "." .Version)._1 }}`:                                           "2",
+		`{{ splitList "." .Version | join "-" }}`:                                 "1-2-3",
+		`{{ join ", " .List }}`:                                                   "a, 1, true",
+		`{{ .Name | contains "serv" }} {{ .Name | contains "x" }}`:                "true false",
+		`{{ .Name | hasPrefix "my" }} {{ .Name | hasSuffix "my" }}`:               "true false",
+		`{{ "ab" | repeat 3 }}`:                                                   "ababab",
+		`{{ substr 0 2 .Name }}|{{ substr 3 -1 .Name }}|{{ substr 3 100 .Name }}`: "my|service|service",
+		`{{ trunc 2 .Name }}|{{ trunc -7 .Name }}|{{ trunc 100 .Name }}`:          "my|service|my-service",
+		`{{ quote .Name "with \"quotes\"" }} {{ .Name | squote }}`:                `"my-service" "with \"quotes\"" 'my-service'`,
+		`{{ camelcase "http_server" }} {{ camelcase "my-service name" }}`:         "HttpServer MyServiceName",
+		`{{ snakecase "FirstName" }} {{ snakecase "HTTPServer" }}`:                "first_name http_server",
+		`{{ kebabcase "FirstName" }} {{ kebabcase "my_service2Name" }}`:           "first-name my-service2-name",
+	} {
+		genCont, err := gen.RenderString(tmpl)
+		require.NoError(t, err, tmpl)
+		require.Equal(t, expected, genCont, tmpl)
+	}
+}
+
+func Test_splitWords(t *testing.T) {
+	require.Equal(t, []string{}, splitWords(""))
+	require.Equal(t, []string{"HTTP", "Server", "name", "2x"}, splitWords("HTTPServer_name-2x"))
+	require.Equal(t, []string{"user", "ID"}, splitWords("userID"))
+	require.Equal(t, []string{"first", "Name"}, splitWords("  first Name "))
+}
@@ -0,0 +1,513 @@
+// Package generator renders gotgen templates: Go text/template files (.gg) with an inventory,
+// optional front matter, partials and layouts.
+//
+// The gotgen commands are thin wrappers around this package, it can be used to embed gotgen into other Go tools:
+//
+//	gen, err := generator.New(generator.Options{
+//		Inventory: map[string]interface{}{"ProjectName": "myapp"},
+//	})
+//	...
+//	files, err := gen.RenderTree(generator.TreeOptions{SourceRoot: "templates", OutputRoot: "build", Recursive: true})
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/gotgen/configs"
+	"github.com/pkg/errors"
+)
+
+// Options configures a Generator
+type Options struct {
+	// Inventory is the data the templates are executed with
+	Inventory map[string]interface{}
+	// DelimiterLeft and DelimiterRight are the template action delimiters, text/template's defaults ({{ and }}) are used if empty
+	DelimiterLeft  string
+	DelimiterRight string
+	// Funcs are additional template functions, available in every template next to the built-in ones.
+	// It's an error if a function has the name of a built-in function (see BuiltinFuncNames), use OverrideFuncs for that.
+	Funcs template.FuncMap
+	// OverrideFuncs are additional template functions, which can replace the built-in functions with the same name
+	OverrideFuncs template.FuncMap
+	// PartialsDir is the directory of the partial templates, which can be used in every template by name.
+	// The name of a partial is its slash separated path relative to PartialsDir without the .gg extension,
+	// e.g. PartialsDir/yaml/common.yml.gg is available as "yaml/common.yml".
+	PartialsDir string
+	// LayoutsDir is the directory where the layouts referenced in the templates' front matter are looked up.
+	// If empty the layouts are looked up in the directory of the template.
+	LayoutsDir string
+	// MissingKey defines what happens if a template uses a key which is not in the inventory, defaults to configs.MissingKeyError.
+	// A template can override it in its front matter.
+	MissingKey configs.MissingKeyMode
+	// FileSystem is used to read the templates, partials and layouts, defaults to OSFileSystem
+	FileSystem FileSystem
+}
+
+// OptionsFromConfig returns the Options defined by a GotGen config.
+// The config's plugins are added as Funcs, see PluginFuncs.
+func OptionsFromConfig(model configs.Model) Options {
+	opts := Options{
+		Inventory:      model.Inventory,
+		DelimiterLeft:  model.Delimiter.Left,
+		DelimiterRight: model.Delimiter.Right,
+		PartialsDir:    model.PartialsDir,
+		LayoutsDir:     model.LayoutsDir,
+		MissingKey:     model.MissingKey,
+	}
+	if len(model.Plugins) > 0 {
+		opts.Funcs = PluginFuncs(model.Plugins)
+	}
+	return opts
+}
+
+// Generator renders templates with the inventory and settings of its Options.
+// Create it with New.
+type Generator struct {
+	inventory      map[string]interface{}
+	delimiterLeft  string
+	delimiterRight string
+	funcs          template.FuncMap
+	partials       []partialTemplate
+	partialsDir    string
+	layoutsDir     string
+	missingKey     configs.MissingKeyMode
+	fs             FileSystem
+}
+
+// New creates a Generator, reading the partial templates if opts has a PartialsDir.
+// Returns an error if any of the custom functions is invalid, or if it collides with a built-in function without being an override.
+func New(opts Options) (*Generator, error) {
+	funcs, err := customFuncs(opts.Funcs, opts.OverrideFuncs)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := opts.MissingKey.Validate(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	gen := &Generator{
+		inventory:      opts.Inventory,
+		delimiterLeft:  opts.DelimiterLeft,
+		delimiterRight: opts.DelimiterRight,
+		funcs:          funcs,
+		partialsDir:    opts.PartialsDir,
+		layoutsDir:     opts.LayoutsDir,
+		missingKey:     opts.MissingKey,
+		fs:             opts.FileSystem,
+	}
+	if gen.inventory == nil {
+		gen.inventory = map[string]interface{}{}
+	}
+	if gen.fs == nil {
+		gen.fs = OSFileSystem{}
+	}
+
+	if len(opts.PartialsDir) > 0 {
+		partials, err := readPartials(gen.fs, opts.PartialsDir)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		gen.partials = partials
+	}
+	return gen, nil
+}
+
+// File is a file rendered by the Generator
+type File struct {
+	// TemplatePath is the path of the template (or for RenderScaffold the copied file) the file was rendered from
+	TemplatePath string
+	Path         string
+	Content      string
+	// Mode is the permission of the file, 0 if not specified
+	Mode os.FileMode
+}
+
+// WriteFile writes the file's content to its path, creating the missing directories.
+// If the file's Mode is not 0 the file's permission is set to it, even if the file already existed.
+func WriteFile(file File) error {
+	if err := pathutil.EnsureDirExist(filepath.Dir(file.Path)); err != nil {
+		return errors.Wrapf(err, "Failed to create directory for generated file (path: %s)", file.Path)
+	}
+	if err := fileutil.WriteStringToFileWithPermission(file.Path, file.Content, file.Mode); err != nil {
+		return errors.Wrapf(err, "Failed to write generated content into file (to path: %s)", file.Path)
+	}
+	if file.Mode != 0 {
+		if err := os.Chmod(file.Path, file.Mode); err != nil {
+			return errors.Wrapf(err, "Failed to set the permission of the generated file (path: %s)", file.Path)
+		}
+	}
+	return nil
+}
+
+// TreeOptions defines which templates of a directory tree are rendered, and where the files are generated
+type TreeOptions struct {
+	// SourceRoot is the directory to search for .gg files in, defaults to the current directory
+	SourceRoot string
+	// OutputRoot is the directory the files are generated into, with the same relative layout the templates have in SourceRoot.
+	// If empty every file is generated next to its template.
+	OutputRoot string
+	// Recursive if false only the templates directly in SourceRoot are used, otherwise the whole directory tree
+	Recursive bool
+	// Includes if specified only the templates matching any of these globs are used
+	Includes []string
+	// Excludes the templates and directories matching any of these globs are skipped.
+	// A glob without a slash is matched against the file or directory name, a glob with a slash against the path relative to SourceRoot,
+	// and a glob ending with a slash only matches directories.
+	Excludes []string
+}
+
+func (tree TreeOptions) sourceRoot() string {
+	if tree.SourceRoot == "" {
+		return "."
+	}
+	return tree.SourceRoot
+}
+
+// FindTemplates returns the templates of the tree, as a map of template path => default output path.
+// The partials and layouts directories, and the layouts referenced by the templates' front matter are skipped.
+func (gen *Generator) FindTemplates(tree TreeOptions) (map[string]string, error) {
+	templateFiles, err := findTemplateFiles(gen.fs, tree.sourceRoot(), tree.OutputRoot, tree.Recursive, tree.Includes, tree.Excludes, []string{gen.partialsDir, gen.layoutsDir})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := removeLayoutTemplates(gen.fs, templateFiles, gen.layoutsDir); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return templateFiles, nil
+}
+
+// DefaultOutputPath returns the path where the file rendered from templatePath is generated by default,
+// see TreeOptions.OutputRoot.
+func (tree TreeOptions) DefaultOutputPath(templatePath string) string {
+	return outputPathForTemplate(templatePath, tree.sourceRoot(), tree.OutputRoot)
+}
+
+// RenderTree renders every template of the tree (see FindTemplates and RenderFile), ordered by template path.
+func (gen *Generator) RenderTree(tree TreeOptions) ([]File, error) {
+	templateFiles, err := gen.FindTemplates(tree)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	files := []File{}
+	for _, aTemplatePth := range sortedTemplatePaths(templateFiles) {
+		renderedFiles, err := gen.RenderFile(aTemplatePth, templateFiles[aTemplatePth])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		files = append(files, renderedFiles...)
+	}
+	return files, nil
+}
+
+// RenderString renders the template content with the inventory. The content is not processed any further,
+// e.g. a front matter is rendered as part of the content.
+func (gen *Generator) RenderString(templateCont string) (string, error) {
+	return gen.execute(templateCont, gen.inventory, renderOptions{})
+}
+
+// RenderTemplate renders the content of the template at templatePath (used to find the template's layout and in error messages).
+// The template can start with a front matter (see frontMatterModel), which is removed before rendering.
+// Matrix templates generate multiple files, they can't be rendered with this function, see RenderFile.
+func (gen *Generator) RenderTemplate(templatePath, templateCont string) (string, error) {
+	prepared, err := gen.prepareTemplate(templatePath, templateCont)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if len(prepared.FrontMatter.Each) > 0 {
+		return "", errors.New("Matrix templates (each in the front matter) generate multiple files, they can't be rendered into a single output")
+	}
+
+	return gen.execute(prepared.Content, prepared.Inventory, prepared.Opts)
+}
+
+// RenderFile renders the template file at templatePath into the files it generates.
+// A matrix template (see frontMatterModel.Each) generates one file for every item, any other template generates a single file.
+//
+// The file is generated to defaultOutputPath (if empty next to the template, without the .gg extension),
+// unless the template's front matter specifies a different output.
+// The directory and file names in defaultOutputPath are rendered too (see renderPath), for a matrix template for every item.
+func (gen *Generator) RenderFile(templatePath, defaultOutputPath string) ([]File, error) {
+	if defaultOutputPath == "" {
+		defaultOutputPath = strings.TrimSuffix(templatePath, TemplateFileExtension)
+	}
+	return gen.renderFile(templatePath, defaultOutputPath, false)
+}
+
+// RenderFileTo renders the template file at templatePath into a single file at outputPath.
+// The output of the template's front matter is ignored, and outputPath is not rendered.
+func (gen *Generator) RenderFileTo(templatePath, outputPath string) (File, error) {
+	files, err := gen.renderFile(templatePath, outputPath, true)
+	if err != nil {
+		return File{}, errors.WithStack(err)
+	}
+	return files[0], nil
+}
+
+func (gen *Generator) renderFile(templatePath, defaultOutputPath string, isOutputPathFixed bool) ([]File, error) {
+	templateCont, err := gen.fs.ReadFile(templatePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read template content (path: %s)", templatePath)
+	}
+
+	files, err := gen.renderTemplateFiles(templatePath, string(templateCont), defaultOutputPath, isOutputPathFixed)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to generate file based on content (%s) - invalid content?", templatePath)
+	}
+	return files, nil
+}
+
+// renderOptions are the options of a single rendering, set from the template's front matter
+type renderOptions struct {
+	// Name is the name of the template in error messages, the template's path
+	Name string
+	// LineOffset is the number of lines removed from the beginning of the template (its front matter),
+	// so that the line numbers in error messages match the template file's lines
+	LineOffset int
+	// Layout if set the template is rendered into this layout: the layout is executed,
+	// and the template can only (re)define the named templates and blocks of the layout
+	Layout *partialTemplate
+	// TemplateDelimiter if set overrides the delimiters for the template itself,
+	// the partials and the layout are still parsed with the Generator's delimiters
+	TemplateDelimiter *configs.DelimiterModel
+	// MissingKey if set overrides the Generator's missing key mode
+	MissingKey configs.MissingKeyMode
+	// Engine is the template package the template is rendered with, engineText if empty
+	Engine templateEngine
+}
+
+// preparedTemplate is a template with its front matter processed
+type preparedTemplate struct {
+	FrontMatter frontMatterModel
+	// Content is the template content without the front matter
+	Content string
+	// Inventory is the inventory, with the front matter's inventory merged into it
+	Inventory map[string]interface{}
+	// Opts are the renderOptions defined by the front matter
+	Opts renderOptions
+}
+
+// prepareTemplate processes the optional front matter of the template at templatePath (see splitFrontMatter).
+func (gen *Generator) prepareTemplate(templatePath, templateCont string) (preparedTemplate, error) {
+	frontMatter, content, err := splitFrontMatter(templateCont)
+	if err != nil {
+		return preparedTemplate{}, errors.WithStack(err)
+	}
+
+	opts := renderOptions{
+		Name:       templatePath,
+		LineOffset: strings.Count(templateCont[:len(templateCont)-len(content)], "\n"),
+	}
+	if len(frontMatter.Layout) > 0 {
+		layout, err := readLayout(gen.fs, frontMatter.Layout, gen.layoutsDir, templatePath)
+		if err != nil {
+			return preparedTemplate{}, errors.WithStack(err)
+		}
+		opts.Layout = &layout
+	}
+	if len(frontMatter.Delimiter.Left) > 0 || len(frontMatter.Delimiter.Right) > 0 {
+		opts.TemplateDelimiter = &frontMatter.Delimiter
+	}
+	if err := frontMatter.MissingKey.Validate(); err != nil {
+		return preparedTemplate{}, errors.Wrap(err, "Invalid missing_key in front matter")
+	}
+	opts.MissingKey = frontMatter.MissingKey
+	if opts.Engine, err = engineForTemplate(templatePath, frontMatter.Engine); err != nil {
+		return preparedTemplate{}, errors.WithStack(err)
+	}
+	inventory := gen.inventory
+	if len(frontMatter.Inventory) > 0 {
+		inventory = configs.MergeInventory(inventory, frontMatter.Inventory)
+	}
+
+	return preparedTemplate{
+		FrontMatter: frontMatter,
+		Content:     content,
+		Inventory:   inventory,
+		Opts:        opts,
+	}, nil
+}
+
+// renderTemplateFiles renders the template at templatePath into the files it generates, see RenderFile.
+// If isOutputPathFixed is true the file is generated to defaultOutputPath as-is, see RenderFileTo.
+func (gen *Generator) renderTemplateFiles(templatePath, templateCont, defaultOutputPath string, isOutputPathFixed bool) ([]File, error) {
+	prepared, err := gen.prepareTemplate(templatePath, templateCont)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	frontMatter := prepared.FrontMatter
+
+	fileMode, err := frontMatter.fileMode()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if len(frontMatter.Each) < 1 {
+		content, err := gen.execute(prepared.Content, prepared.Inventory, prepared.Opts)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		outputPath := defaultOutputPath
+		if !isOutputPathFixed {
+			renderedOutputPath, err := gen.renderPath(defaultOutputPath, prepared.Inventory)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			outputPath = frontMatter.outputPath(renderedOutputPath)
+		}
+		return []File{{TemplatePath: templatePath, Path: outputPath, Content: content, Mode: fileMode}}, nil
+	}
+
+	if isOutputPathFixed {
+		return nil, errors.New("Matrix templates (each in the front matter) generate multiple files, the output file path can't be specified for them")
+	}
+	if len(frontMatter.Output) < 1 && !gen.isTemplatedPath(defaultOutputPath) {
+		return nil, errors.New("Matrix templates (each in the front matter) have to specify the output path template in the front matter (e.g. output: services/{{ .Item.Name }}.yml), or have a templated file name")
+	}
+
+	eachValue, err := configs.InventoryValue(prepared.Inventory, frontMatter.Each)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid each in front matter")
+	}
+	items, err := matrixItems(eachValue)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid each in front matter: %s", frontMatter.Each)
+	}
+
+	// the output path is rendered without the layout
+	outputPathOpts := renderOptions{
+		Name:              templatePath + " (front matter output)",
+		TemplateDelimiter: prepared.Opts.TemplateDelimiter,
+		MissingKey:        prepared.Opts.MissingKey,
+	}
+
+	files := []File{}
+	filePaths := map[string]interface{}{}
+	for _, anItem := range items {
+		itemInventory := matrixItemInventory(prepared.Inventory, frontMatter.matrixItemName(), anItem)
+
+		outputPath, err := gen.renderPath(defaultOutputPath, itemInventory)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to render the output path for item: %v", anItem.Key)
+		}
+		if len(frontMatter.Output) > 0 {
+			output, err := gen.execute(frontMatter.Output, itemInventory, outputPathOpts)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to render the output path for item: %v", anItem.Key)
+			}
+			outputPath = frontMatterModel{Output: output}.outputPath(outputPath)
+		}
+		if otherKey, isFound := filePaths[outputPath]; isFound {
+			return nil, errors.Errorf("Items %v and %v would generate the same file (%s), the output path template has to be unique for every item", otherKey, anItem.Key, outputPath)
+		}
+		filePaths[outputPath] = anItem.Key
+
+		content, err := gen.execute(prepared.Content, itemInventory, prepared.Opts)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to render item: %v", anItem.Key)
+		}
+		files = append(files, File{TemplatePath: templatePath, Path: outputPath, Content: content, Mode: fileMode})
+	}
+	return files, nil
+}
+
+// execute parses the template content (with the partials, and the layout and delimiters of opts) and executes it with the inventory.
+func (gen *Generator) execute(templateCont string, inventory map[string]interface{}, opts renderOptions) (string, error) {
+	var tmpl engineTemplate
+
+	// the inventory is shared by every template, the changes made by a template (e.g. with set or merge) must not leak into the others
+	inventory = configs.CopyInventory(inventory)
+
+	funcs := createAvailableTemplateFunctions(inventory)
+	// include executes a named template (e.g. a partial) and returns the result as a string,
+	// so that it can be used in a pipeline, unlike the template action
+	include := func(name string, data interface{}) (string, error) {
+		var buf bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+	funcs["include"] = include
+	if opts.Engine == engineHTML {
+		// the result is already escaped by html/template, it must not be escaped again
+		funcs["include"] = func(name string, data interface{}) (htmltemplate.HTML, error) {
+			content, err := include(name, data)
+			return htmltemplate.HTML(content), err
+		}
+	}
+	// the custom functions are validated in New, they can only replace a built-in function if it's an explicit override
+	for name, fn := range gen.funcs {
+		funcs[name] = fn
+	}
+
+	rootName := opts.Name
+	if opts.Layout != nil {
+		rootName = opts.Layout.Name
+	}
+
+	missingKey := configs.MissingKeyError
+	if opts.MissingKey != "" {
+		missingKey = opts.MissingKey
+	} else if gen.missingKey != "" {
+		missingKey = gen.missingKey
+	}
+
+	tmpl = newEngineTemplate(opts.Engine, rootName, funcs, missingKey).Delims(gen.delimiterLeft, gen.delimiterRight)
+	// partials are parsed first, so that a template can redefine the templates defined in the partials
+	for _, aPartial := range gen.partials {
+		if _, err := tmpl.New(aPartial.Name).Parse(aPartial.Content); err != nil {
+			return "", errors.WithStack(err)
+		}
+	}
+	contentTmpl := tmpl
+	if opts.Layout != nil {
+		// the layout is the executed root template, and the template is parsed after it,
+		// so that the templates it defines override the layout's blocks
+		if _, err := tmpl.Parse(opts.Layout.Content); err != nil {
+			return "", errors.WithStack(err)
+		}
+		contentTmpl = tmpl.New(opts.Name)
+	}
+	if opts.TemplateDelimiter != nil {
+		contentTmpl = contentTmpl.Delims(opts.TemplateDelimiter.Left, opts.TemplateDelimiter.Right)
+	}
+	if _, err := contentTmpl.Parse(templateCont); err != nil {
+		return "", offsetErrorLines(err, opts)
+	}
+
+	var resBuffer bytes.Buffer
+	if err := tmpl.Execute(&resBuffer, inventory); err != nil {
+		return "", offsetErrorLines(err, opts)
+	}
+	return resBuffer.String(), nil
+}
+
+// offsetErrorLines adds the LineOffset of opts to the line numbers of the template in a text/template error message
+// (e.g. template: conf.yml.gg:2:3: ... or unclosed action started at conf.yml.gg:2), so that they match the template file's lines.
+func offsetErrorLines(err error, opts renderOptions) error {
+	if opts.LineOffset < 1 || opts.Name == "" {
+		return errors.WithStack(err)
+	}
+
+	linePattern := regexp.MustCompile(`(template: |html/template:|started at )` + regexp.QuoteMeta(opts.Name) + `:(\d+)`)
+	msg := linePattern.ReplaceAllStringFunc(err.Error(), func(match string) string {
+		lineIdx := strings.LastIndex(match, ":")
+		line, err := strconv.Atoi(match[lineIdx+1:])
+		if err != nil {
+			return match
+		}
+		return fmt.Sprintf("%s:%d", match[:lineIdx], line+opts.LineOffset)
+	})
+	return errors.New(msg)
+}
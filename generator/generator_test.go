@@ -0,0 +1,523 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/bitrise-io/go-utils/envutil"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/gotgen/configs"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGenerator(t *testing.T, opts Options) *Generator {
+	gen, err := New(opts)
+	require.NoError(t, err)
+	return gen
+}
+
+func renderString(t *testing.T, templateCont string, inventory map[string]interface{}, delimiterLeft, delimiterRight string) (string, error) {
+	return newTestGenerator(t, Options{Inventory: inventory, DelimiterLeft: delimiterLeft, DelimiterRight: delimiterRight}).RenderString(templateCont)
+}
+
+func TestGenerator_RenderString(t *testing.T) {
+	t.Log("Simple string template, empty inventory - no substitution")
+	{
+		genCont, err := renderString(t, `Test Content`, nil, "{{", "}}")
+		require.NoError(t, err)
+		require.Equal(t, `Test Content`, genCont)
+	}
+
+	t.Log("Missing inventory key")
+	{
+		genCont, err := renderString(t, `Test {{ .KeyOne }} Content`, nil, "{{", "}}")
+		require.EqualError(t, err, `template: :1:8: executing "" at <.KeyOne>: map has no entry for key "KeyOne"`)
+		require.Equal(t, ``, genCont)
+	}
+
+	t.Log("Simple substitution")
+	{
+		genCont, err := renderString(t,
+			`Test {{ .KeyOne }} Content`,
+			map[string]interface{}{"KeyOne": "Value 1"},
+			"{{", "}}",
+		)
+		require.NoError(t, err)
+		require.Equal(t, `Test Value 1 Content`, genCont)
+	}
+
+	t.Log("Template function: var: key found")
+	{
+		genCont, err := renderString(t,
+			`Test {{ var "KeyOne" }} Content`,
+			map[string]interface{}{"KeyOne": "Value 1"},
+			"{{", "}}",
+		)
+		require.NoError(t, err)
+		require.Equal(t, `Test Value 1 Content`, genCont)
+	}
+	t.Log("Template function: var: key NOT found")
+	{
+		genCont, err := renderString(t,
+			`Test {{ var "NonExistingKey" }} Content`,
+			map[string]interface{}{"KeyOne": "Value 1"},
+			"{{", "}}",
+		)
+		require.EqualError(t, err, `template: :1:8: executing "" at <var "NonExistingKey">: error calling var: No value found for key: NonExistingKey`)
+		require.Equal(t, ``, genCont)
+	}
+
+	t.Log("Template function: var, varOr, hasVar: key paths")
+	{
+		inventory := map[string]interface{}{
+			"Services": []interface{}{
+				map[string]interface{}{"Name": "api", "Ports": []interface{}{80, 443}},
+			},
+			"Dotted.Key": "dotted",
+		}
+		for tmpl, expected := range map[string]string{
+			`{{ var "Services[0].Name" }} {{ var "Services[0].Ports[1]" }}`: "api 443",
+			`{{ var "Dotted.Key" }}`: "dotted",
+			`{{ varOr "Services[0].Name" "x" }} {{ varOr "Services[1].Name" "x" }}`: "api x",
+			`{{ varOr "Services[0].Name.Sub" 8 }}`:                                  "8",
+			`{{ hasVar "Services[0].Ports" }} {{ hasVar "Services[0].Host" }}`:      "true false",
+			`{{ fromJson "{\"a\": [{\"b\": 1}]}" | lookup "a[0].b" }}`:              "1",
+		} {
+			genCont, err := renderString(t, tmpl, inventory, "{{", "}}")
+			require.NoError(t, err, tmpl)
+			require.Equal(t, expected, genCont, tmpl)
+		}
+
+		_, err := renderString(t, `{{ var "Services[0].Host" }}`, inventory, "{{", "}}")
+		require.EqualError(t, err, `template: :1:3: executing "" at <var "Services[0].Host">: error calling var: No value found for key: Services[0].Host`)
+
+		_, err = renderString(t, `{{ var "Services[1].Name" }}`, inventory, "{{", "}}")
+		require.EqualError(t, err, `template: :1:3: executing "" at <var "Services[1].Name">: error calling var: No value found for key: Services[1].Name - index out of range: Services[1] (length: 1)`)
+
+		_, err = renderString(t, `{{ varOr "Services[x]" 1 }}`, inventory, "{{", "}}")
+		require.Error(t, err)
+	}
+
+	t.Log("Template function: getenv")
+	{
+		revokeFn, err := envutil.RevokableSetenv("Test_generateContent_KEY", "Test Env Value")
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, revokeFn())
+		}()
+
+		genCont, err := renderString(t,
+			`Test {{ getenv "Test_generateContent_KEY" }} Content`,
+			map[string]interface{}{"KeyOne": "Value 1"},
+			"{{", "}}",
+		)
+		require.NoError(t, err)
+		require.Equal(t, `Test Test Env Value Content`, genCont)
+	}
+
+	t.Log("Template function: getenvRequired: found")
+	{
+		revokeFn, err := envutil.RevokableSetenv("Test_generateContent_KEY", "Test Env Value")
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, revokeFn())
+		}()
+
+		genCont, err := renderString(t,
+			`Test {{ getenvRequired "Test_generateContent_KEY" }} Content`,
+			map[string]interface{}{"KeyOne": "Value 1"},
+			"{{", "}}",
+		)
+		require.NoError(t, err)
+		require.Equal(t, `Test Test Env Value Content`, genCont)
+	}
+
+	t.Log("Template function: getenvRequired: NOT found")
+	{
+		genCont, err := renderString(t,
+			`Test {{ getenvRequired "Test_generateContent_KEY_2" }} Content`,
+			map[string]interface{}{"KeyOne": "Value 1"},
+			"{{", "}}",
+		)
+		require.EqualError(t, err, "template: :1:8: executing \"\" at <getenvRequired \"Test_generateContent_KEY_2\">: error calling getenvRequired: No environment variable value found for key: Test_generateContent_KEY_2")
+		require.Equal(t, ``, genCont)
+	}
+}
+
+func TestNew_customFuncs(t *testing.T) {
+	t.Log("Custom function")
+	{
+		gen, err := New(Options{Funcs: template.FuncMap{
+			"version": func(s string) string { return "v" + s },
+		}})
+		require.NoError(t, err)
+		genCont, err := gen.RenderString(`{{ version "1.2" }} {{ 1 | add 2 }}`)
+		require.NoError(t, err)
+		require.Equal(t, "v1.2 3", genCont)
+	}
+
+	t.Log("Collision with a built-in function")
+	{
+		_, err := New(Options{Funcs: template.FuncMap{"yaml": strings.ToUpper}})
+		require.EqualError(t, err, "Template function (yaml) collides with a built-in function, use OverrideFuncs to override it")
+
+		_, err = New(Options{Funcs: template.FuncMap{"len": strings.ToUpper}})
+		require.EqualError(t, err, "Template function (len) collides with a built-in function, use OverrideFuncs to override it")
+
+		_, err = New(Options{Funcs: template.FuncMap{"include": strings.ToUpper}})
+		require.Error(t, err)
+	}
+
+	t.Log("Explicit override of a built-in function")
+	{
+		gen, err := New(Options{OverrideFuncs: template.FuncMap{
+			"getenv": func(key string) string { return "fake " + key },
+		}})
+		require.NoError(t, err)
+		genCont, err := gen.RenderString(`{{ getenv "HOME" }}`)
+		require.NoError(t, err)
+		require.Equal(t, "fake HOME", genCont)
+	}
+
+	t.Log("Both in Funcs and OverrideFuncs")
+	{
+		_, err := New(Options{
+			Funcs:         template.FuncMap{"version": strings.ToUpper},
+			OverrideFuncs: template.FuncMap{"version": strings.ToLower},
+		})
+		require.EqualError(t, err, "Template function (version) is specified both in Funcs and OverrideFuncs")
+	}
+
+	t.Log("Invalid function")
+	{
+		_, err := New(Options{Funcs: template.FuncMap{"not-an-identifier": strings.ToUpper}})
+		require.Error(t, err)
+
+		_, err = New(Options{Funcs: template.FuncMap{"notAFunction": "value"}})
+		require.Error(t, err)
+	}
+}
+
+func TestGenerator_execute(t *testing.T) {
+	gen := &Generator{
+		delimiterLeft:  "{{",
+		delimiterRight: "}}",
+		partials: []partialTemplate{
+			{Name: "header", Content: `# Header for {{ .KeyOne }}`},
+			{Name: "block", Content: "a: {{ .KeyOne }}\nb: 2\n"},
+			{Name: "defines", Content: `{{ define "greeting" }}Hello {{ . }}{{ end }}`},
+		},
+	}
+	inventory := map[string]interface{}{"KeyOne": "Value 1"}
+
+	t.Log("Partial with the template action")
+	{
+		genCont, err := gen.execute(`{{ template "header" . }}
+Content`, inventory, renderOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "# Header for Value 1\nContent", genCont)
+	}
+
+	t.Log("Partial with the include function, in a pipeline")
+	{
+		genCont, err := gen.execute(`root:
+{{ include "block" . | indentWithSpaces 2 }}`, inventory, renderOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "root:\n  a: Value 1\n  b: 2\n  ", genCont)
+	}
+
+	t.Log("Template defined in a partial")
+	{
+		genCont, err := gen.execute(`{{ template "greeting" .KeyOne }}`, inventory, renderOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "Hello Value 1", genCont)
+	}
+
+	t.Log("Template defined in a partial can be redefined by the template")
+	{
+		genCont, err := gen.execute(`{{ define "greeting" }}Hi {{ . }}{{ end }}{{ template "greeting" .KeyOne }}`, inventory, renderOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "Hi Value 1", genCont)
+	}
+
+	t.Log("Missing partial")
+	{
+		_, err := gen.execute(`{{ include "missing" . }}`, inventory, renderOptions{})
+		require.EqualError(t, err, `template: :1:3: executing "" at <include "missing" .>: error calling include: template: no template "missing" associated with template ""`)
+	}
+
+	t.Log("Invalid partial")
+	{
+		invalidGen := &Generator{
+			partials: []partialTemplate{{Name: "invalid", Content: `{{ .KeyOne `}},
+		}
+		_, err := invalidGen.execute(`Content`, inventory, renderOptions{})
+		require.Error(t, err)
+	}
+}
+
+func TestGenerator_RenderTemplate(t *testing.T) {
+	tmpDir := createTestTree(t)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "base.gg"), `# {{ block "title" . }}Default title{{ end }}
+{{ block "body" . }}Default body{{ end }}
+Footer: {{ .KeyOne }}`))
+	inventory := map[string]interface{}{"KeyOne": "Value 1"}
+	gen := newTestGenerator(t, Options{Inventory: inventory, LayoutsDir: tmpDir})
+
+	t.Log("Without front matter")
+	{
+		genCont, err := gen.RenderTemplate("template.txt.gg", `Test {{ .KeyOne }} Content`)
+		require.NoError(t, err)
+		require.Equal(t, `Test Value 1 Content`, genCont)
+	}
+
+	t.Log("Multi-document YAML template starting with a --- separator")
+	{
+		genCont, err := gen.RenderTemplate("manifest.yml.gg", "---\napiVersion: v1\nname: {{ .KeyOne }}\n---\nkind: Service\n")
+		require.NoError(t, err)
+		require.Equal(t, "---\napiVersion: v1\nname: Value 1\n---\nkind: Service\n", genCont)
+
+		genCont, err = gen.RenderTemplate("manifest.yml.gg", "---\napiVersion: v1\nname: {{ .KeyOne }}\n")
+		require.NoError(t, err)
+		require.Equal(t, "---\napiVersion: v1\nname: Value 1\n", genCont)
+	}
+
+	t.Log("Layout - overriding a block, keeping the default of the other")
+	{
+		genCont, err := gen.RenderTemplate("template.txt.gg", `---gotgen
+layout: base
+---
+{{ define "body" }}Body of {{ .KeyOne }}{{ end }}`)
+		require.NoError(t, err)
+		require.Equal(t, "# Default title\nBody of Value 1\nFooter: Value 1", genCont)
+	}
+
+	t.Log("Layout - overriding every block")
+	{
+		genCont, err := gen.RenderTemplate("template.txt.gg", `---gotgen
+layout: base
+---
+{{ define "title" }}Title{{ end }}
+{{ define "body" }}Body{{ end }}`)
+		require.NoError(t, err)
+		require.Equal(t, "# Title\nBody\nFooter: Value 1", genCont)
+	}
+
+	t.Log("Front matter - delimiters and local inventory")
+	{
+		genCont, err := gen.RenderTemplate("template.txt.gg", `---gotgen
+output: other.txt
+delimiter:
+  left: "[["
+  right: "]]"
+inventory:
+  Local: local value
+  KeyOne: overridden
+---
+{{ literal }} [[ .KeyOne ]] [[ .Local ]]`)
+		require.NoError(t, err)
+		require.Equal(t, "{{ literal }} overridden local value", genCont)
+		// the inventory is not modified
+		require.Equal(t, map[string]interface{}{"KeyOne": "Value 1"}, inventory)
+	}
+
+	t.Log("Front matter delimiters with a layout - the layout uses the config's delimiters")
+	{
+		genCont, err := gen.RenderTemplate("template.txt.gg", `---gotgen
+layout: base
+delimiter:
+  left: "[["
+  right: "]]"
+---
+[[ define "body" ]]{{ literal }}[[ end ]]`)
+		require.NoError(t, err)
+		require.Equal(t, "# Default title\n{{ literal }}\nFooter: Value 1", genCont)
+	}
+
+	t.Log("Missing layout")
+	{
+		_, err := gen.RenderTemplate("template.txt.gg", "---gotgen\nlayout: missing\n---\n")
+		require.Error(t, err)
+	}
+
+	t.Log("Front matter - missing key mode")
+	{
+		genCont, err := gen.RenderTemplate("template.txt.gg", "---gotgen\nmissing_key: zero\n---\n{{ .Missing | default \"fallback\" }} {{ .Missing }}")
+		require.NoError(t, err)
+		require.Equal(t, "fallback <no value>", genCont)
+
+		_, err = gen.RenderTemplate("template.txt.gg", "{{ .Missing }}")
+		require.Error(t, err)
+
+		_, err = gen.RenderTemplate("template.txt.gg", "---gotgen\nmissing_key: invalid\n---\n")
+		require.EqualError(t, err, "Invalid missing_key in front matter: Invalid missing key mode: invalid, has to be one of: error, zero, default")
+	}
+}
+
+func TestGenerator_missingKey(t *testing.T) {
+	t.Log("Options - missing key mode")
+	{
+		gen := newTestGenerator(t, Options{MissingKey: configs.MissingKeyDefault})
+		genCont, err := gen.RenderString("{{ .Missing }}")
+		require.NoError(t, err)
+		require.Equal(t, "<no value>", genCont)
+
+		genCont, err = gen.RenderTemplate("template.txt.gg", "---gotgen\nmissing_key: error\n---\n{{ .Missing }}")
+		require.Error(t, err)
+		require.Equal(t, "", genCont)
+	}
+
+	t.Log("Options - invalid missing key mode")
+	{
+		_, err := New(Options{MissingKey: "invalid"})
+		require.Error(t, err)
+	}
+}
+
+func TestGenerator_renderTemplateFiles(t *testing.T) {
+	inventory := map[string]interface{}{
+		"Services": []interface{}{
+			map[string]interface{}{"Name": "api", "Port": 8080},
+			map[string]interface{}{"Name": "web", "Port": 80},
+		},
+		"Envs": map[string]interface{}{
+			"staging": map[string]interface{}{"Host": "staging.example.com"},
+			"prod":    map[string]interface{}{"Host": "example.com"},
+		},
+	}
+	gen := newTestGenerator(t, Options{Inventory: inventory})
+
+	t.Log("Not a matrix template - a single file")
+	{
+		files, err := gen.renderTemplateFiles("a/conf.yml.gg", "---gotgen\noutput: other.yml\nmode: \"0600\"\n---\nservices: {{ len .Services }}", "a/conf.yml", false)
+		require.NoError(t, err)
+		require.Equal(t, []File{{TemplatePath: "a/conf.yml.gg", Path: "a/other.yml", Content: "services: 2", Mode: 0600}}, files)
+	}
+
+	t.Log("Not a matrix template - fixed output path")
+	{
+		files, err := gen.renderTemplateFiles("a/conf.yml.gg", "---gotgen\noutput: other.yml\n---\nconf", "fixed.yml", true)
+		require.NoError(t, err)
+		require.Equal(t, []File{{TemplatePath: "a/conf.yml.gg", Path: "fixed.yml", Content: "conf"}}, files)
+	}
+
+	t.Log("Matrix over a list")
+	{
+		files, err := gen.renderTemplateFiles("a/service.yml.gg", `---gotgen
+each: Services
+as: Service
+output: services/{{ .Service.Name }}.yml
+---
+{{ .ServiceKey }}: {{ .Service.Name }}:{{ .Service.Port }}`, "a/service.yml", false)
+		require.NoError(t, err)
+		require.Equal(t, []File{
+			{TemplatePath: "a/service.yml.gg", Path: "a/services/api.yml", Content: "0: api:8080"},
+			{TemplatePath: "a/service.yml.gg", Path: "a/services/web.yml", Content: "1: web:80"},
+		}, files)
+	}
+
+	t.Log("Matrix over a map - sorted by key, default item name, custom delimiters")
+	{
+		files, err := gen.renderTemplateFiles("a/env.gg", `---gotgen
+each: Envs
+output: "[[ .ItemKey ]].env"
+delimiter:
+  left: "[["
+  right: "]]"
+---
+HOST=[[ .Item.Host ]]`, "a/env", false)
+		require.NoError(t, err)
+		require.Equal(t, []File{
+			{TemplatePath: "a/env.gg", Path: "a/prod.env", Content: "HOST=example.com"},
+			{TemplatePath: "a/env.gg", Path: "a/staging.env", Content: "HOST=staging.example.com"},
+		}, files)
+		// the inventory is not modified
+		require.Equal(t, 2, len(inventory))
+	}
+
+	t.Log("Matrix over Go lists and maps of any type")
+	{
+		goGen := newTestGenerator(t, Options{Inventory: map[string]interface{}{
+			"Names": []string{"a", "b"},
+			"Ports": map[string]int{"web": 80, "api": 8080},
+		}})
+		files, err := goGen.renderTemplateFiles("name.gg", "---gotgen\neach: Names\noutput: \"{{ .Item }}.txt\"\n---\n{{ .ItemKey }}", "name", false)
+		require.NoError(t, err)
+		require.Equal(t, []File{
+			{TemplatePath: "name.gg", Path: "a.txt", Content: "0"},
+			{TemplatePath: "name.gg", Path: "b.txt", Content: "1"},
+		}, files)
+
+		files, err = goGen.renderTemplateFiles("port.gg", "---gotgen\neach: Ports\noutput: \"{{ .ItemKey }}.txt\"\n---\n{{ .Item }}", "port", false)
+		require.NoError(t, err)
+		require.Equal(t, []File{
+			{TemplatePath: "port.gg", Path: "api.txt", Content: "8080"},
+			{TemplatePath: "port.gg", Path: "web.txt", Content: "80"},
+		}, files)
+	}
+
+	t.Log("Templated file and directory names")
+	{
+		files, err := gen.renderTemplateFiles("{{ .Envs.prod.Host }}/conf.yml.gg", "conf", "{{ .Envs.prod.Host }}/conf.yml", false)
+		require.NoError(t, err)
+		require.Equal(t, []File{{TemplatePath: "{{ .Envs.prod.Host }}/conf.yml.gg", Path: "example.com/conf.yml", Content: "conf"}}, files)
+
+		files, err = gen.renderTemplateFiles("{{ .Envs.prod.Host }}/conf.yml.gg", "conf", "fixed.yml", true)
+		require.NoError(t, err)
+		require.Equal(t, []File{{TemplatePath: "{{ .Envs.prod.Host }}/conf.yml.gg", Path: "fixed.yml", Content: "conf"}}, files)
+	}
+
+	t.Log("Matrix - templated file name instead of output")
+	{
+		files, err := gen.renderTemplateFiles("a/{{ .Item.Name }}.yml.gg", "---gotgen\neach: Services\n---\nport: {{ .Item.Port }}", "a/{{ .Item.Name }}.yml", false)
+		require.NoError(t, err)
+		require.Equal(t, []File{
+			{TemplatePath: "a/{{ .Item.Name }}.yml.gg", Path: "a/api.yml", Content: "port: 8080"},
+			{TemplatePath: "a/{{ .Item.Name }}.yml.gg", Path: "a/web.yml", Content: "port: 80"},
+		}, files)
+	}
+
+	t.Log("Matrix - YAML content starting with a --- separator, and a plain --- first line is not a matrix template")
+	{
+		files, err := gen.renderTemplateFiles("a/{{ .Item.Name }}.yml.gg", "---gotgen\neach: Services\n---\n---\nport: {{ .Item.Port }}\n", "a/{{ .Item.Name }}.yml", false)
+		require.NoError(t, err)
+		require.Equal(t, []File{
+			{TemplatePath: "a/{{ .Item.Name }}.yml.gg", Path: "a/api.yml", Content: "---\nport: 8080\n"},
+			{TemplatePath: "a/{{ .Item.Name }}.yml.gg", Path: "a/web.yml", Content: "---\nport: 80\n"},
+		}, files)
+
+		files, err = gen.renderTemplateFiles("a/list.yml.gg", "---\neach: Services\n---\ncount: {{ len .Services }}", "a/list.yml", false)
+		require.NoError(t, err)
+		require.Equal(t, []File{{TemplatePath: "a/list.yml.gg", Path: "a/list.yml", Content: "---\neach: Services\n---\ncount: 2"}}, files)
+	}
+
+	t.Log("Matrix - errors")
+	{
+		_, err := gen.renderTemplateFiles("a/env.gg", "---gotgen\neach: Envs\n---\n", "a/env", false)
+		require.Error(t, err, "output is required")
+
+		_, err = gen.renderTemplateFiles("a/env.gg", "---gotgen\neach: Envs\noutput: \"{{ .ItemKey }}\"\n---\n", "a/env", true)
+		require.Error(t, err, "fixed output path")
+
+		_, err = gen.renderTemplateFiles("a/env.gg", "---gotgen\neach: Missing\noutput: \"{{ .ItemKey }}\"\n---\n", "a/env", false)
+		require.EqualError(t, err, "Invalid each in front matter: No value found for key: Missing")
+
+		_, err = gen.renderTemplateFiles("a/env.gg", "---gotgen\neach: Envs.prod.Host\noutput: \"{{ .ItemKey }}\"\n---\n", "a/env", false)
+		require.EqualError(t, err, "Invalid each in front matter: Envs.prod.Host: has to be a list or a map, but it is string")
+
+		_, err = gen.renderTemplateFiles("a/env.gg", "---gotgen\neach: Envs\noutput: same.env\n---\n", "a/env", false)
+		require.EqualError(t, err, "Items prod and staging would generate the same file (a/same.env), the output path template has to be unique for every item")
+	}
+
+	t.Log("Matrix templates can't be rendered into a single output")
+	{
+		_, err := gen.RenderTemplate("a/env.gg", "---gotgen\neach: Envs\noutput: \"{{ .ItemKey }}\"\n---\n")
+		require.Error(t, err)
+	}
+}
@@ -0,0 +1,56 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// readLayout reads the layout template defined in a template's front matter.
+//
+// The layout is looked up in layoutsDir, or if layoutsDir is empty in the directory of the template (templatePath).
+// The .gg extension can be omitted from the layout name, e.g. "base" and "base.gg" both refer to the base.gg file.
+// The name of the returned template is the layout name, without the .gg extension.
+func readLayout(fs FileSystem, layout, layoutsDir, templatePath string) (partialTemplate, error) {
+	pth, err := layoutPath(fs, layout, layoutsDir, templatePath)
+	if err != nil {
+		return partialTemplate{}, errors.WithStack(err)
+	}
+
+	content, err := fs.ReadFile(pth)
+	if err != nil {
+		return partialTemplate{}, errors.Wrapf(err, "Failed to read layout (path: %s)", pth)
+	}
+	return partialTemplate{
+		Name:    strings.TrimSuffix(filepath.ToSlash(layout), TemplateFileExtension),
+		Content: string(content),
+	}, nil
+}
+
+// layoutPath returns the path of the layout, see readLayout.
+// If the layout name has no .gg extension the <name>.gg file is tried first, and then the file with the exact name.
+func layoutPath(fs FileSystem, layout, layoutsDir, templatePath string) (string, error) {
+	dir := layoutsDir
+	if dir == "" {
+		dir = filepath.Dir(templatePath)
+	}
+
+	candidates := []string{}
+	if !strings.HasSuffix(layout, TemplateFileExtension) {
+		candidates = append(candidates, filepath.Join(dir, layout+TemplateFileExtension))
+	}
+	candidates = append(candidates, filepath.Join(dir, layout))
+
+	for _, aPth := range candidates {
+		if _, err := fs.Stat(aPth); os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return "", errors.Wrapf(err, "Failed to check if layout exists (path: %s)", aPth)
+		}
+		return aPth, nil
+	}
+
+	return "", errors.Errorf("Layout (%s) not found, searched for: %s", layout, strings.Join(candidates, ", "))
+}
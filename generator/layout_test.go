@@ -0,0 +1,52 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_readLayout(t *testing.T) {
+	tmpDir := createTestTree(t, "layouts/base.gg", "layouts/base", "layouts/html/page.html", "templates/local.gg")
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	layoutsDir := filepath.Join(tmpDir, "layouts")
+	templatePth := filepath.Join(tmpDir, "templates", "a.txt.gg")
+
+	t.Log("From the layouts dir, without the .gg extension - the .gg file is used even if a file with the exact name exists")
+	{
+		layout, err := readLayout(OSFileSystem{}, "base", layoutsDir, templatePth)
+		require.NoError(t, err)
+		require.Equal(t, partialTemplate{Name: "base", Content: "layouts/base.gg"}, layout)
+	}
+
+	t.Log("From the layouts dir, with the .gg extension")
+	{
+		layout, err := readLayout(OSFileSystem{}, "base.gg", layoutsDir, templatePth)
+		require.NoError(t, err)
+		require.Equal(t, partialTemplate{Name: "base", Content: "layouts/base.gg"}, layout)
+	}
+
+	t.Log("From the layouts dir, other extension in a subdirectory")
+	{
+		layout, err := readLayout(OSFileSystem{}, "html/page.html", layoutsDir, templatePth)
+		require.NoError(t, err)
+		require.Equal(t, partialTemplate{Name: "html/page.html", Content: "layouts/html/page.html"}, layout)
+	}
+
+	t.Log("Next to the template, if there's no layouts dir")
+	{
+		layout, err := readLayout(OSFileSystem{}, "local", "", templatePth)
+		require.NoError(t, err)
+		require.Equal(t, partialTemplate{Name: "local", Content: "templates/local.gg"}, layout)
+	}
+
+	t.Log("Not found")
+	{
+		_, err := readLayout(OSFileSystem{}, "missing", layoutsDir, templatePth)
+		require.EqualError(t, err, "Layout (missing) not found, searched for: "+filepath.Join(layoutsDir, "missing.gg")+", "+filepath.Join(layoutsDir, "missing"))
+	}
+}
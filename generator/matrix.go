@@ -0,0 +1,54 @@
+package generator
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// defaultMatrixItemName is the inventory key of the current item in a matrix template,
+// if the template's front matter does not specify one
+const defaultMatrixItemName = "Item"
+
+// matrixItem is an item of the list or map a matrix template iterates over
+type matrixItem struct {
+	// Key is the index of the item in the list, or its key in the map
+	Key   interface{}
+	Value interface{}
+}
+
+// matrixItems returns the items of a list, or of a map (sorted by key) for a matrix template.
+// The list and the map can be of any type (e.g. []string or map[string]int in the Options.Inventory),
+// the keys of the map are converted to strings.
+func matrixItems(value interface{}) ([]matrixItem, error) {
+	if listValues, isList := listItems(value); isList {
+		items := make([]matrixItem, len(listValues))
+		for idx, val := range listValues {
+			items[idx] = matrixItem{Key: idx, Value: val}
+		}
+		return items, nil
+	}
+
+	if mapValue := reflect.ValueOf(value); mapValue.Kind() == reflect.Map {
+		keyValues := sortedDictKeyValues(mapValue)
+		items := make([]matrixItem, len(keyValues))
+		for idx, aKey := range keyValues {
+			items[idx] = matrixItem{Key: fmt.Sprintf("%v", aKey.Interface()), Value: mapValue.MapIndex(aKey).Interface()}
+		}
+		return items, nil
+	}
+	return nil, errors.Errorf("has to be a list or a map, but it is %T", value)
+}
+
+// matrixItemInventory returns a copy of the inventory with the item set under itemName,
+// and the item's key (list index or map key) under itemName + "Key".
+func matrixItemInventory(inventory map[string]interface{}, itemName string, item matrixItem) map[string]interface{} {
+	itemInventory := make(map[string]interface{}, len(inventory)+2)
+	for key, val := range inventory {
+		itemInventory[key] = val
+	}
+	itemInventory[itemName] = item.Value
+	itemInventory[itemName+"Key"] = item.Key
+	return itemInventory
+}
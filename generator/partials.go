@@ -0,0 +1,58 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// partialTemplate is a named template which is available in every template,
+// with the template action ({{ template "name" . }}) and with the include function ({{ include "name" . }})
+type partialTemplate struct {
+	Name    string
+	Content string
+}
+
+// readPartials reads every file in the partialsDir directory tree as a partialTemplate.
+//
+// The name of the partial is the file's slash separated path relative to partialsDir, without the .gg extension,
+// e.g. the name of partialsDir/header.gg is "header", and the name of partialsDir/yaml/common.yml.gg is "yaml/common.yml".
+func readPartials(fs FileSystem, partialsDir string) ([]partialTemplate, error) {
+	partials := []partialTemplate{}
+
+	walkFn := func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		relPth, err := filepath.Rel(partialsDir, pth)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		content, err := fs.ReadFile(pth)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to read partial template (path: %s)", pth)
+		}
+
+		partials = append(partials, partialTemplate{
+			Name:    strings.TrimSuffix(filepath.ToSlash(relPth), TemplateFileExtension),
+			Content: string(content),
+		})
+		return nil
+	}
+
+	if err := fs.Walk(partialsDir, walkFn); err != nil {
+		return nil, errors.Wrapf(err, "Failed to read partial templates (from: %s)", partialsDir)
+	}
+
+	sort.Slice(partials, func(i, j int) bool {
+		return partials[i].Name < partials[j].Name
+	})
+	return partials, nil
+}
@@ -0,0 +1,29 @@
+package generator
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_readPartials(t *testing.T) {
+	tmpDir := createTestTree(t, "header.gg", "yaml/common.yml.gg", "footer.txt")
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	partials, err := readPartials(OSFileSystem{}, tmpDir)
+	require.NoError(t, err)
+	require.Equal(t, []partialTemplate{
+		{Name: "footer.txt", Content: "footer.txt"},
+		{Name: "header", Content: "header.gg"},
+		{Name: "yaml/common.yml", Content: "yaml/common.yml.gg"},
+	}, partials)
+
+	t.Log("Missing directory")
+	{
+		_, err := readPartials(OSFileSystem{}, "/non/existing/dir")
+		require.Error(t, err)
+	}
+}
@@ -0,0 +1,107 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/bitrise-io/gotgen/configs"
+	"github.com/pkg/errors"
+)
+
+// pluginRequest is sent to the plugin executable as JSON on its standard input
+type pluginRequest struct {
+	Function string        `json:"function"`
+	Args     []interface{} `json:"args"`
+}
+
+// pluginResponse is read from the plugin executable's standard output, as JSON.
+// If Error is not empty the template function fails with it, otherwise it returns Result.
+type pluginResponse struct {
+	Result interface{} `json:"result"`
+	Error  string      `json:"error"`
+}
+
+// plugin is a template function implemented by an external executable.
+//
+// On every call the executable is run with the function's name and arguments as JSON on its standard input
+// (e.g. {"function": "semver", "args": ["1.2.3", "minor"]}), and it has to print either {"result": ...}
+// or {"error": "..."} as JSON on its standard output.
+// The results are cached by argument list, so the executable is only run once for the same arguments.
+// Every call returns a copy of the cached result, so that modifying it (e.g. with set) doesn't modify the cache.
+// Integer numbers in the result are decoded as int (like in the inventory and fromJson), every other number as float64.
+type plugin struct {
+	name    string
+	command string
+	args    []string
+
+	cacheMutex sync.Mutex
+	cache      map[string]interface{}
+}
+
+// PluginFuncs returns the template functions implemented by the plugin executables (see configs.PluginModel),
+// which can be used as Options.Funcs.
+func PluginFuncs(plugins map[string]configs.PluginModel) template.FuncMap {
+	funcs := template.FuncMap{}
+	for name, aPlugin := range plugins {
+		p := &plugin{
+			name:    name,
+			command: aPlugin.Command,
+			args:    aPlugin.Args,
+			cache:   map[string]interface{}{},
+		}
+		funcs[name] = p.call
+	}
+	return funcs
+}
+
+func (p *plugin) call(args ...interface{}) (interface{}, error) {
+	if args == nil {
+		args = []interface{}{}
+	}
+	request, err := json.Marshal(pluginRequest{Function: p.name, Args: args})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to encode the arguments of plugin (%s)", p.name)
+	}
+
+	// the arguments are the only part of the request which can change between calls
+	cacheKey := string(request)
+	p.cacheMutex.Lock()
+	defer p.cacheMutex.Unlock()
+	if result, isFound := p.cache[cacheKey]; isFound {
+		return configs.CopyValue(result), nil
+	}
+
+	result, err := p.run(request)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	p.cache[cacheKey] = result
+	return configs.CopyValue(result), nil
+}
+
+func (p *plugin) run(request []byte) (interface{}, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(p.command, p.args...)
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "Plugin (%s) failed (command: %s), error output: %s", p.name, p.command, strings.TrimSpace(stderr.String()))
+	}
+
+	var response pluginResponse
+	decoder := json.NewDecoder(bytes.NewReader(stdout.Bytes()))
+	decoder.UseNumber()
+	if err := decoder.Decode(&response); err != nil {
+		return nil, errors.Wrapf(err, "Plugin (%s) returned an invalid response, has to be a JSON object with either a result or an error: %s", p.name, strings.TrimSpace(stdout.String()))
+	}
+	if response.Error != "" {
+		return nil, errors.Errorf("Plugin (%s) returned an error: %s", p.name, response.Error)
+	}
+	return convertJSONNumbers(response.Result), nil
+}
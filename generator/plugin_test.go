@@ -0,0 +1,98 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/gotgen/configs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginFuncs(t *testing.T) {
+	tmpDir := createTestTree(t)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	// echoes the request as the result, and counts the calls
+	echoPth := filepath.Join(tmpDir, "echo.sh")
+	callsPth := filepath.Join(tmpDir, "calls.txt")
+	require.NoError(t, fileutil.WriteStringToFileWithPermission(echoPth, `#!/bin/sh
+echo call >> "$1"
+printf '{"result": %s}' "$(cat)"
+`, 0755))
+	failPth := filepath.Join(tmpDir, "failing.sh")
+	require.NoError(t, fileutil.WriteStringToFileWithPermission(failPth, `#!/bin/sh
+printf '{"error": "invalid version: %s"}' "$1"
+`, 0755))
+	invalidPth := filepath.Join(tmpDir, "invalid.sh")
+	require.NoError(t, fileutil.WriteStringToFileWithPermission(invalidPth, `#!/bin/sh
+echo "not json"
+`, 0755))
+
+	gen := newTestGenerator(t, Options{Funcs: PluginFuncs(map[string]configs.PluginModel{
+		"echo":    {Command: echoPth, Args: []string{callsPth}},
+		"failing": {Command: failPth, Args: []string{"x.y"}},
+		"invalid": {Command: invalidPth},
+		"missing": {Command: filepath.Join(tmpDir, "missing.sh")},
+	})})
+
+	t.Log("Request and result")
+	{
+		genCont, err := gen.RenderString(`{{ $res := echo "a" 1 }}{{ $res.function }} {{ index $res.args 0 }} {{ index $res.args 1 }}`)
+		require.NoError(t, err)
+		require.Equal(t, "echo a 1", genCont)
+	}
+
+	t.Log("Results are cached by argument list")
+	{
+		genCont, err := gen.RenderString(`{{ (echo "a" 1).function }} {{ (echo "b").function }} {{ (echo "a" 1).function }}`)
+		require.NoError(t, err)
+		require.Equal(t, "echo echo echo", genCont)
+
+		calls, err := fileutil.ReadStringFromFile(callsPth)
+		require.NoError(t, err)
+		require.Equal(t, "call\ncall\n", calls)
+	}
+
+	t.Log("Modifying a cached result doesn't modify the cache")
+	{
+		genCont, err := gen.RenderString(`{{ $_ := set (echo "c") "function" "changed" }}{{ $_ := set (echo "c") "n" 99 }}{{ (echo "c").function }}`)
+		require.NoError(t, err)
+		require.Equal(t, "echo", genCont)
+
+		genCont, err = gen.RenderString(`{{ (echo "c").function }} {{ hasKey (echo "c") "n" }}`)
+		require.NoError(t, err)
+		require.Equal(t, "echo false", genCont)
+	}
+
+	t.Log("Integer numbers are decoded as int, like in fromJson")
+	{
+		genCont, err := gen.RenderString(`{{ $res := echo 1 1.5 }}{{ printf "%T %T" (index $res.args 0) (index $res.args 1) }}`)
+		require.NoError(t, err)
+		require.Equal(t, "int float64", genCont)
+	}
+
+	t.Log("Error returned by the plugin")
+	{
+		_, err := gen.RenderString(`{{ failing }}`)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Plugin (failing) returned an error: invalid version: x.y")
+	}
+
+	t.Log("Invalid response")
+	{
+		_, err := gen.RenderString(`{{ invalid }}`)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Plugin (invalid) returned an invalid response")
+	}
+
+	t.Log("Missing executable")
+	{
+		_, err := gen.RenderString(`{{ missing }}`)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Plugin (missing) failed")
+	}
+}
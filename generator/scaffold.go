@@ -0,0 +1,116 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// isTemplatedPath reports whether the path contains template expressions (see renderPath)
+func (gen *Generator) isTemplatedPath(pth string) bool {
+	left := gen.delimiterLeft
+	if left == "" {
+		left = "{{"
+	}
+	return strings.Contains(pth, left)
+}
+
+// renderPath renders the template expressions in the directory and file names of pth,
+// e.g. {{ .ProjectName }}/cmd/{{ .ProjectName }}.go => myapp/cmd/myapp.go.
+// The path is rendered with the delimiters, partials and functions of the Generator, but without a layout.
+// It's an error if a directory or file name renders to an empty string.
+func (gen *Generator) renderPath(pth string, inventory map[string]interface{}) (string, error) {
+	if !gen.isTemplatedPath(pth) {
+		return pth, nil
+	}
+
+	renderedPth, err := gen.execute(pth, inventory, renderOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to render path: %s", pth)
+	}
+
+	sep := string(filepath.Separator)
+	isEmptyNameRendered := renderedPth == "" ||
+		strings.Contains(renderedPth, sep+sep) ||
+		strings.HasSuffix(renderedPth, sep) ||
+		(strings.HasPrefix(renderedPth, sep) && !strings.HasPrefix(pth, sep))
+	if isEmptyNameRendered {
+		return "", errors.Errorf("Path (%s) renders to an empty file or directory name: %s", pth, renderedPth)
+	}
+	return renderedPth, nil
+}
+
+// RenderScaffold renders a new directory tree in toDir from the template directory fromDir (cookiecutter style).
+//
+// Every directory and file name in fromDir is rendered (see renderPath). The .gg files are rendered
+// (see RenderFile) without the .gg extension, every other file is copied as-is, keeping its permission.
+// The files and directories in skipPaths (e.g. the scaffold's config) and the .git directories are skipped, empty items are ignored.
+// Nothing is written, see WriteFile. It's an error if two files would be generated to the same path.
+func (gen *Generator) RenderScaffold(fromDir, toDir string, skipPaths []string) ([]File, error) {
+	skipPthMap := map[string]bool{}
+	for _, aPth := range skipPaths {
+		if aPth != "" {
+			skipPthMap[filepath.Clean(aPth)] = true
+		}
+	}
+
+	files := []File{}
+	walkFn := func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPth, err := filepath.Rel(fromDir, pth)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if relPth == "." {
+			return nil
+		}
+
+		if skipPthMap[filepath.Clean(pth)] || (info.IsDir() && info.Name() == ".git") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		if strings.HasSuffix(pth, TemplateFileExtension) {
+			renderedFiles, err := gen.RenderFile(pth, filepath.Join(toDir, strings.TrimSuffix(relPth, TemplateFileExtension)))
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			files = append(files, renderedFiles...)
+			return nil
+		}
+
+		outputPth, err := gen.renderPath(filepath.Join(toDir, relPth), gen.inventory)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		content, err := gen.fs.ReadFile(pth)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to read file (path: %s)", pth)
+		}
+		files = append(files, File{TemplatePath: pth, Path: outputPth, Content: string(content), Mode: info.Mode().Perm()})
+		return nil
+	}
+
+	if err := gen.fs.Walk(fromDir, walkFn); err != nil {
+		return nil, errors.Wrapf(err, "Failed to scaffold directory (from: %s)", fromDir)
+	}
+
+	templatePths := map[string]string{}
+	for _, aFile := range files {
+		if otherTemplatePth, isFound := templatePths[aFile.Path]; isFound {
+			return nil, errors.Errorf("%s and %s would create the same file (%s)", otherTemplatePth, aFile.TemplatePath, aFile.Path)
+		}
+		templatePths[aFile.Path] = aFile.TemplatePath
+	}
+	return files, nil
+}
@@ -0,0 +1,97 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_renderPath(t *testing.T) {
+	inventory := map[string]interface{}{"ProjectName": "myapp", "Empty": ""}
+	gen := newTestGenerator(t, Options{})
+
+	t.Log("Not templated")
+	{
+		pth, err := gen.renderPath("a/b.go", inventory)
+		require.NoError(t, err)
+		require.Equal(t, "a/b.go", pth)
+	}
+
+	t.Log("Directory and file names")
+	{
+		pth, err := gen.renderPath("/root/{{ .ProjectName }}/cmd/{{ .ProjectName }}.go", inventory)
+		require.NoError(t, err)
+		require.Equal(t, "/root/myapp/cmd/myapp.go", pth)
+	}
+
+	t.Log("Custom delimiters")
+	{
+		customGen := newTestGenerator(t, Options{DelimiterLeft: "[[", DelimiterRight: "]]"})
+		pth, err := customGen.renderPath("[[ .ProjectName ]]/{{ literal }}.go", inventory)
+		require.NoError(t, err)
+		require.Equal(t, "myapp/{{ literal }}.go", pth)
+	}
+
+	t.Log("Empty name")
+	{
+		_, err := gen.renderPath("{{ .Empty }}/main.go", inventory)
+		require.EqualError(t, err, "Path ({{ .Empty }}/main.go) renders to an empty file or directory name: /main.go")
+
+		_, err = gen.renderPath("a/{{ .Empty }}/main.go", inventory)
+		require.Error(t, err)
+
+		_, err = gen.renderPath("a/{{ .Empty }}", inventory)
+		require.Error(t, err)
+	}
+
+	t.Log("Missing key")
+	{
+		_, err := gen.renderPath("{{ .Missing }}/main.go", inventory)
+		require.Error(t, err)
+	}
+}
+
+func TestGenerator_RenderScaffold(t *testing.T) {
+	fromDir := createTestTree(t,
+		"gg.conf.json",
+		"{{ .ProjectName }}/cmd/{{ .ProjectName }}.go.gg",
+		"{{ .ProjectName }}/static.txt",
+		".git/HEAD",
+	)
+	defer func() {
+		require.NoError(t, os.RemoveAll(fromDir))
+	}()
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(fromDir, "{{ .ProjectName }}/cmd/{{ .ProjectName }}.go.gg"), "package main // {{ .ProjectName }}"))
+	require.NoError(t, os.Chmod(filepath.Join(fromDir, "{{ .ProjectName }}/static.txt"), 0755))
+	gen := newTestGenerator(t, Options{Inventory: map[string]interface{}{"ProjectName": "myapp"}})
+
+	t.Log("Templates are rendered, other files are copied, the skipped files and .git are ignored")
+	{
+		files, err := gen.RenderScaffold(fromDir, "out", []string{filepath.Join(fromDir, "gg.conf.json")})
+		require.NoError(t, err)
+		require.Equal(t, []File{
+			{
+				TemplatePath: filepath.Join(fromDir, "{{ .ProjectName }}/cmd/{{ .ProjectName }}.go.gg"),
+				Path:         "out/myapp/cmd/myapp.go",
+				Content:      "package main // myapp",
+			},
+			{
+				TemplatePath: filepath.Join(fromDir, "{{ .ProjectName }}/static.txt"),
+				Path:         "out/myapp/static.txt",
+				Content:      "{{ .ProjectName }}/static.txt",
+				Mode:         0755,
+			},
+		}, files)
+	}
+
+	t.Log("Two files rendered to the same path")
+	{
+		require.NoError(t, os.MkdirAll(filepath.Join(fromDir, "myapp"), 0755))
+		require.NoError(t, fileutil.WriteStringToFile(filepath.Join(fromDir, "myapp/static.txt"), "conflict"))
+		_, err := gen.RenderScaffold(fromDir, "out", []string{filepath.Join(fromDir, "gg.conf.json")})
+		require.Error(t, err)
+	}
+}
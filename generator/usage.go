@@ -0,0 +1,194 @@
+package generator
+
+import (
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/bitrise-io/gotgen/configs"
+	"github.com/pkg/errors"
+)
+
+// keyPathFuncNames are the functions which get an inventory key path as their first argument, e.g. var "Nested.KeyA"
+var keyPathFuncNames = map[string]bool{"var": true, "varOr": true, "hasVar": true}
+
+// UnusedInventoryKeys returns the top level inventory keys which are not used by any of the templates, in alphabetical order.
+//
+// The templates are analyzed statically, without rendering them: a key is used if it's referenced by a template,
+// its front matter (each, output), layout or templated file name, or by any of the partials, even if that part is never executed.
+// A key is referenced with e.g. .Key or .Key.Sub (outside of range and with), $.Key, var "Key.Sub" or each: Key.
+// If a template uses the whole inventory (e.g. {{ toJson . }}) every key is used.
+func (gen *Generator) UnusedInventoryKeys(templatePaths []string) ([]string, error) {
+	usage := inventoryKeyUsage{keys: map[string]bool{}}
+
+	for _, aPartial := range gen.partials {
+		if err := gen.addTemplateUsage(&usage, aPartial.Name, aPartial.Content, nil); err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse partial: %s", aPartial.Name)
+		}
+	}
+
+	for _, aTemplatePth := range templatePaths {
+		templateCont, err := gen.fs.ReadFile(aTemplatePth)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to read template content (path: %s)", aTemplatePth)
+		}
+		prepared, err := gen.prepareTemplate(aTemplatePth, string(templateCont))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse template: %s", aTemplatePth)
+		}
+
+		usage.addKeyPath(prepared.FrontMatter.Each)
+		for _, content := range []string{prepared.Content, prepared.FrontMatter.Output} {
+			if err := gen.addTemplateUsage(&usage, aTemplatePth, content, prepared.Opts.TemplateDelimiter); err != nil {
+				return nil, errors.Wrapf(err, "Failed to parse template: %s", aTemplatePth)
+			}
+		}
+		if gen.isTemplatedPath(aTemplatePth) {
+			if err := gen.addTemplateUsage(&usage, aTemplatePth, aTemplatePth, nil); err != nil {
+				return nil, errors.Wrapf(err, "Failed to parse template path: %s", aTemplatePth)
+			}
+		}
+		if prepared.Opts.Layout != nil {
+			if err := gen.addTemplateUsage(&usage, prepared.Opts.Layout.Name, prepared.Opts.Layout.Content, nil); err != nil {
+				return nil, errors.Wrapf(err, "Failed to parse layout: %s", prepared.Opts.Layout.Name)
+			}
+		}
+	}
+
+	if usage.isAllUsed {
+		return []string{}, nil
+	}
+	unusedKeys := []string{}
+	for key := range gen.inventory {
+		if !usage.keys[key] {
+			unusedKeys = append(unusedKeys, key)
+		}
+	}
+	sort.Strings(unusedKeys)
+	return unusedKeys, nil
+}
+
+// addTemplateUsage parses the template content (with the Generator's delimiters, unless delimiter is set),
+// and adds the inventory keys it uses to usage.
+func (gen *Generator) addTemplateUsage(usage *inventoryKeyUsage, name, content string, delimiter *configs.DelimiterModel) error {
+	funcs := createAvailableTemplateFunctions(nil)
+	// the template is only parsed, never executed
+	funcs["include"] = func(string, interface{}) (string, error) { return "", nil }
+	for name, fn := range gen.funcs {
+		funcs[name] = fn
+	}
+
+	tmpl := template.New(name).Funcs(funcs).Delims(gen.delimiterLeft, gen.delimiterRight)
+	if delimiter != nil {
+		tmpl = tmpl.Delims(delimiter.Left, delimiter.Right)
+	}
+	if _, err := tmpl.Parse(content); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, aTemplate := range tmpl.Templates() {
+		if aTemplate.Tree != nil {
+			usage.walk(aTemplate.Tree.Root, true)
+		}
+	}
+	return nil
+}
+
+// inventoryKeyUsage collects the top level inventory keys used by templates
+type inventoryKeyUsage struct {
+	keys map[string]bool
+	// isAllUsed is true if a template uses the whole inventory, e.g. {{ toJson . }}
+	isAllUsed bool
+}
+
+// addKeyPath adds the first key of the keyPath (e.g. Nested of Nested.KeyA or Services of Services[0].Name),
+// and the whole keyPath, which can be a top level key containing dots.
+func (usage *inventoryKeyUsage) addKeyPath(keyPath string) {
+	if keyPath == "" {
+		return
+	}
+	usage.keys[keyPath] = true
+	if idx := strings.IndexAny(keyPath, ".["); idx >= 0 {
+		usage.keys[keyPath[:idx]] = true
+	}
+}
+
+// walk adds the keys used by the node and its children.
+// isDotRoot is true if dot is the inventory at the node, which is not the case in the body of range and with.
+func (usage *inventoryKeyUsage) walk(node parse.Node, isDotRoot bool) {
+	switch typedNode := node.(type) {
+	case *parse.ListNode:
+		if typedNode == nil {
+			return
+		}
+		for _, aNode := range typedNode.Nodes {
+			usage.walk(aNode, isDotRoot)
+		}
+	case *parse.ActionNode:
+		usage.walkPipe(typedNode.Pipe, isDotRoot, false)
+	case *parse.IfNode:
+		usage.walkBranch(typedNode.BranchNode, isDotRoot, isDotRoot)
+	case *parse.RangeNode:
+		usage.walkBranch(typedNode.BranchNode, isDotRoot, false)
+	case *parse.WithNode:
+		usage.walkBranch(typedNode.BranchNode, isDotRoot, false)
+	case *parse.TemplateNode:
+		// the named templates are analyzed on their own, passing the inventory to them doesn't use every key
+		usage.walkPipe(typedNode.Pipe, isDotRoot, true)
+	}
+}
+
+func (usage *inventoryKeyUsage) walkBranch(branch parse.BranchNode, isDotRoot, isBodyDotRoot bool) {
+	usage.walkPipe(branch.Pipe, isDotRoot, false)
+	usage.walk(branch.List, isBodyDotRoot)
+	usage.walk(branch.ElseList, isDotRoot)
+}
+
+// walkPipe adds the keys used by the commands of the pipeline.
+// isDotPassed is true if the pipeline's value is passed to a named template.
+func (usage *inventoryKeyUsage) walkPipe(pipe *parse.PipeNode, isDotRoot, isDotPassed bool) {
+	if pipe == nil {
+		return
+	}
+	for _, aCmd := range pipe.Cmds {
+		funcName := ""
+		if identifier, isIdentifier := aCmd.Args[0].(*parse.IdentifierNode); isIdentifier {
+			funcName = identifier.Ident
+		}
+		for idx, anArg := range aCmd.Args {
+			isKeyPathArg := idx == 1 && keyPathFuncNames[funcName]
+			usage.walkArg(anArg, isDotRoot, isDotPassed || funcName == "include", isKeyPathArg)
+		}
+	}
+}
+
+func (usage *inventoryKeyUsage) walkArg(arg parse.Node, isDotRoot, isDotPassed, isKeyPathArg bool) {
+	switch typedArg := arg.(type) {
+	case *parse.FieldNode:
+		if isDotRoot {
+			usage.keys[typedArg.Ident[0]] = true
+		}
+	case *parse.VariableNode:
+		if typedArg.Ident[0] != "$" {
+			return
+		}
+		if len(typedArg.Ident) > 1 {
+			usage.keys[typedArg.Ident[1]] = true
+		} else if !isDotPassed {
+			usage.isAllUsed = true
+		}
+	case *parse.DotNode:
+		if isDotRoot && !isDotPassed {
+			usage.isAllUsed = true
+		}
+	case *parse.ChainNode:
+		// e.g. (.Nested).KeyA
+		usage.walkArg(typedArg.Node, isDotRoot, false, false)
+	case *parse.PipeNode:
+		usage.walkPipe(typedArg, isDotRoot, false)
+	case *parse.StringNode:
+		if isKeyPathArg {
+			usage.addKeyPath(typedArg.Text)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package generator
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_UnusedInventoryKeys(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gotgen-test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	writeTemplate := func(relPth, content string) string {
+		pth := filepath.Join(tmpDir, relPth)
+		require.NoError(t, os.MkdirAll(filepath.Dir(pth), 0755))
+		require.NoError(t, fileutil.WriteStringToFile(pth, content))
+		return pth
+	}
+
+	partialsDir := filepath.Join(tmpDir, "partials")
+	writeTemplate("partials/header.gg", `{{ define "header" }}{{ .FromPartial }}{{ end }}`)
+	writeTemplate("layouts/base.gg", `{{ .FromLayout }}{{ block "content" . }}{{ end }}`)
+	fieldsPth := writeTemplate("fields.txt.gg", "---gotgen\nlayout: base\n---\n"+
+		`{{ template "header" . }}{{ .Field.Sub }} {{ $.Dollar }} {{ (.Chained).Key }} {{ if .InIf }}{{ .InIfBody }}{{ end }}`+
+		`{{ range .Services }}{{ .NotRoot }}{{ $.InRange }}{{ else }}{{ .InRangeElse }}{{ end }}{{ with .With }}{{ .NotRoot2 }}{{ end }}`+
+		`{{ var "Var.Key" }} {{ varOr "VarOr[0]" 1 }} {{ hasVar "Dotted.Top" }} {{ include "header" . }} {{ upper (print .InPipe) }}`)
+	matrixPth := writeTemplate("{{ .PathKey }}.txt.gg", "---gotgen\neach: Each\noutput: \"[[ .OutputKey ]]/[[ .Item ]].txt\"\ndelimiter:\n  left: \"[[\"\n  right: \"]]\"\n---\n[[ .Delimited ]]")
+	usesAllPth := writeTemplate("all.txt.gg", `{{ toJson . }}`)
+
+	inventory := map[string]interface{}{}
+	for _, key := range []string{
+		"FromPartial", "FromLayout", "Field", "Dollar", "Chained", "InIf", "InIfBody", "Services", "InRange", "InRangeElse", "With",
+		"Var", "VarOr", "Dotted.Top", "InPipe", "PathKey", "Each", "OutputKey", "Delimited",
+		"NotRoot", "NotRoot2", "Unused",
+	} {
+		inventory[key] = "value"
+	}
+	gen := newTestGenerator(t, Options{Inventory: inventory, PartialsDir: partialsDir, LayoutsDir: filepath.Join(tmpDir, "layouts")})
+
+	t.Log("Only the keys used by the templates")
+	{
+		unusedKeys, err := gen.UnusedInventoryKeys([]string{fieldsPth, matrixPth})
+		require.NoError(t, err)
+		require.Equal(t, []string{"NotRoot", "NotRoot2", "Unused"}, unusedKeys)
+	}
+
+	t.Log("A template which uses the whole inventory")
+	{
+		unusedKeys, err := gen.UnusedInventoryKeys([]string{fieldsPth, usesAllPth})
+		require.NoError(t, err)
+		require.Equal(t, []string{}, unusedKeys)
+	}
+
+	t.Log("Invalid template")
+	{
+		invalidPth := writeTemplate("invalid.txt.gg", `{{ .Unclosed `)
+		_, err := gen.UnusedInventoryKeys([]string{invalidPth})
+		require.Error(t, err)
+	}
+}
@@ -3,8 +3,10 @@ module github.com/bitrise-io/gotgen
 go 1.12
 
 require (
+	github.com/BurntSushi/toml v0.3.1
 	github.com/bitrise-io/go-utils v0.0.0-20190613135528-7a4402b387eb
 	github.com/pkg/errors v0.8.1
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/spf13/cobra v0.0.5
 	github.com/stretchr/testify v1.3.0
 	gopkg.in/yaml.v2 v2.2.2